@@ -48,14 +48,120 @@ proxy: #Service
 	config:          #SpaceConfig
 }
 
+#GameMode: "ffa" | "coop" | "insta" | "instateam" | "effic" | "efficteam" | "tac" | "tacteam" | "capture" | "regencapture" | "ctf" | "instactf" | "efficctf" | "protect" | "instaprotect" | "efficprotect" | "hold" | "instahold" | "effichold" | "collect" | "instacollect" | "efficcollect"
+
 #ServerConfig: {
 	maxClients: uint8 | *128
 	// Length of game in seconds
 	matchLength:      uint | *600
-	defaultGameSpeed: uint8 | *100
-	defaultMode:      "ffa" | "coop" | "insta" | "instateam" | "effic" | "efficteam" | "tac" | "tacteam" | "ctf" | "instactf" | "efficctf" | *"ffa"
+	// In percent of real time
+	defaultGameSpeed: uint16 & >=10 & <=1000 | *100
+	defaultMode:      #GameMode | *"ffa"
 	defaultMap:       string | *"complex"
 	maps:             [...string] | *[]
+	// How long clients are banned for after being kicked, in seconds. 0
+	// disables banning kicked clients. Defaults to four hours, like vanilla.
+	kickBanDuration: uint | *14400
+	// What to do with players that move in ways the game's physics don't
+	// allow, like speed hacks or teleporting.
+	cheatAction: "warn" | "spectate" | "kick" | *"warn"
+	// Keys players can claim privileges with using the game's /auth and
+	// /sauth commands.
+	authKeys: [...#AuthKey] | *[]
+	// Percentage of players that have to vote for a map for it to be played
+	// right away.
+	voteMajority: uint & <=100 | *50
+	// Seconds a map vote stays open before the map with the most votes wins.
+	voteDuration: uint | *30
+	// What to play after each match. If empty, the server keeps the mode
+	// and picks one of `maps` at random.
+	rotation: [...#RotationEntry] | *[]
+	// Whether to go through the rotation in a random order.
+	shuffleRotation: bool | *false
+	// How many of the maps played last not to play again for now.
+	rotationHistory: uint | *0
+	// Seconds after which players that don't move, shoot or chat are moved
+	// to spectators. 0 disables this.
+	afkTimeout: uint | *0
+	// Seconds after which idle spectators are disconnected to make room when
+	// the server is almost full. 0 disables this.
+	afkSpectatorTimeout: uint | *0
+	// Play instagib, efficiency and tactics modes (and their team variants)
+	// as elimination: everyone gets one life per round and the last player
+	// or team standing wins the round.
+	elimination: bool | *false
+	// Play gun game instead of free for all modes: every frag gives players
+	// the next weapon of the ladder and the first to get a frag with the
+	// last one wins. The game can only hand out weapons by spawning players,
+	// so getting the next weapon moves players to a spawn point, and they
+	// lose quad damage and any rockets or grenades still in flight.
+	gunGame: bool | *false
+	// The weapons players go through in gun game. If empty, it's chaingun,
+	// shotgun, rifle, rocket launcher, grenade launcher, pistol and chainsaw.
+	gunGameLadder: [...#Weapon] | *[]
+	// Move players between teams during matches to keep the teams even, and
+	// suggest swaps at intermission when one team is much stronger.
+	balanceTeams: bool | *false
+	// How many times each team may pause a clan war. With 0, teams can't
+	// pause at all.
+	clanWarPauses: uint | *2
+	// How many seconds a tied clan war goes into overtime for. With 0, the
+	// next team to score wins.
+	clanWarOvertime: uint | *0
+	// Changes to the stock weapons, like how much damage they deal or how
+	// much ammo players spawn with. Clients always fire the stock number of
+	// rays and never shoot farther than the stock range, so rays and range
+	// can only be lowered.
+	weapons: [...#WeaponConfig] | *[]
+	// Health and armour players spawn with. 0 keeps what the mode gives
+	// them.
+	spawnHealth: uint | *0
+	spawnArmour: uint | *0
+	// The weapon players hold when they spawn, if they have ammo for it.
+	spawnWeapon?: #Weapon
+	// How many seconds items take to respawn, instead of the stock delays.
+	// Clients still add the stock amounts when they pick them up.
+	pickupRespawn: [...#PickupRespawn] | *[]
+}
+
+#Weapon: "chainsaw" | "shotgun" | "chaingun" | "rocketlauncher" | "rifle" | "grenadelauncher" | "pistol"
+
+#WeaponConfig: {
+	weapon: #Weapon
+	// Damage per ray. 0 keeps the stock damage.
+	damage: uint | *0
+	// How many rays of a shot can hit, at most 20 for the shotgun and 1 for
+	// everything else. 0 keeps the stock number.
+	rays: uint & <=20 | *0
+	// How far the weapon hits, at most 2048 for the rifle, 14 for the
+	// chainsaw and 1024 for everything else. 0 keeps the stock range.
+	range: number & >=0 & <=2048 | *0
+	// How much ammo players spawn with. 0 keeps what the mode gives them,
+	// negative values take it away.
+	ammo: int | *0
+}
+
+#PickupRespawn: {
+	item:    "shells" | "bullets" | "rockets" | "riflerounds" | "grenades" | "cartridges" | "health" | "healthboost" | "greenarmour" | "yellowarmour" | "quaddamage"
+	seconds: uint & >=1
+}
+
+#RotationEntry: {
+	// The current mode if not set
+	mode?: #GameMode
+	map:   string
+	// Only play this entry when there are at least minPlayers and at most
+	// maxPlayers players. 0 means there's no limit.
+	minPlayers: uint | *0
+	maxPlayers: uint | *0
+}
+
+#AuthKey: {
+	name:   string
+	domain: string | *""
+	// The public key, as printed by the game's /genauthkey
+	key:  string
+	role: "master" | "auth" | "admin" | *"auth"
 }
 
 #ServerPreset: {
@@ -96,6 +202,21 @@ proxy: #Service
 	duel: [...#DuelType]
 }
 
+#ChatFilter: {
+	// A regular expression, matched regardless of case
+	pattern: string
+	// "replace" stars out the matching text, "block" keeps the whole
+	// message from being sent.
+	action: "replace" | "block" | *"replace"
+}
+
+#ChatSettings: {
+	// Users may send at most floodMessages messages within floodSeconds.
+	floodMessages: uint | *5
+	floodSeconds:  uint | *5
+	filters: [...#ChatFilter] | *[]
+}
+
 #Port: uint16
 
 #ENetIngress: {
@@ -191,6 +312,7 @@ assetStores: [...#AssetStore] | *[{
 
 	matchmaking: #MatchmakingSettings
 	ingress:     #IngressSettings
+	chat:        #ChatSettings
 
 	// We set the Sauerbraten `serverdesc` according to this template.
 	// #id is replaced with the server's identifier.
@@ -677,6 +677,16 @@ type Bases struct {
 
 func (m Bases) Type() MessageCode { return N_BASES }
 
+type ClientBaseState struct {
+	AmmoType int32
+	Position Vec
+}
+type ClientBases struct {
+	Bases []ClientBaseState
+}
+
+func (m ClientBases) Type() MessageCode { return N_BASES }
+
 // N_BASEINFO
 type BaseInfo struct {
 	Base      int32
@@ -705,6 +715,11 @@ type ReplenishAmmo struct {
 
 func (m ReplenishAmmo) Type() MessageCode { return N_REPAMMO }
 
+type ClientReplenishAmmo struct {
+}
+
+func (m ClientReplenishAmmo) Type() MessageCode { return N_REPAMMO }
+
 // N_TRYSPAWN
 type TrySpawn struct {
 }
@@ -743,6 +758,16 @@ type InitTokens struct {
 
 func (m InitTokens) Type() MessageCode { return N_INITTOKENS }
 
+type ClientTokenBase struct {
+	Team     int32
+	Position Vec
+}
+type ClientInitTokens struct {
+	Bases []ClientTokenBase
+}
+
+func (m ClientInitTokens) Type() MessageCode { return N_INITTOKENS }
+
 // N_TAKETOKEN
 type TakeToken struct {
 	Client int32
@@ -752,26 +777,34 @@ type TakeToken struct {
 
 func (m TakeToken) Type() MessageCode { return N_TAKETOKEN }
 
+type ClientTakeToken struct {
+	Token int32
+}
+
+func (m ClientTakeToken) Type() MessageCode { return N_TAKETOKEN }
+
 // N_EXPIRETOKENS
+type ExpiredToken struct {
+	Token int32
+}
 type ExpireTokens struct {
-	Tokens []struct {
-		Token int32
-	} `type:"term"`
+	Tokens []ExpiredToken `type:"term"`
 }
 
 func (m ExpireTokens) Type() MessageCode { return N_EXPIRETOKENS }
 
 // N_DROPTOKENS
+type DroppedToken struct {
+	Token int32
+	Team  int32
+	Yaw   int32
+}
 type DropTokens struct {
 	Client int32
 	Dropx  int32
 	Dropy  int32
 	Dropz  int32
-	Tokens []struct {
-		Token int32
-		Team  int32
-		Yaw   int32
-	} `type:"term"`
+	Tokens []DroppedToken `type:"term"`
 }
 
 func (m DropTokens) Type() MessageCode { return N_DROPTOKENS }
@@ -786,11 +819,7 @@ type StealTokens struct {
 	Dropx     int32
 	Dropy     int32
 	Dropz     int32
-	Tokens    []struct {
-		Token int32
-		Team  int32
-		Yaw   int32
-	} `type:"term"`
+	Tokens    []DroppedToken `type:"term"`
 }
 
 func (m StealTokens) Type() MessageCode { return N_STEALTOKENS }
@@ -807,6 +836,12 @@ type DepositTokens struct {
 
 func (m DepositTokens) Type() MessageCode { return N_DEPOSITTOKENS }
 
+type ClientDepositTokens struct {
+	Base int32
+}
+
+func (m ClientDepositTokens) Type() MessageCode { return N_DEPOSITTOKENS }
+
 // N_ITEMLIST
 type Item struct {
 	Index int32
@@ -1063,7 +1098,6 @@ func init() {
 	registerBoth(&BaseInfo{})
 	registerBoth(&BaseRegen{})
 	registerBoth(&BaseScore{})
-	registerBoth(&Bases{})
 	registerBoth(&BotBalance{})
 	registerBoth(&BotLimit{})
 	registerBoth(&CheckMaps{})
@@ -1078,7 +1112,6 @@ func init() {
 	registerBoth(&DelBot{})
 	registerBoth(&DemoPacket{})
 	registerBoth(&DemoPlayback{})
-	registerBoth(&DepositTokens{})
 	registerBoth(&Died{})
 	registerBoth(&DropFlag{})
 	registerBoth(&DropTokens{})
@@ -1096,7 +1129,6 @@ func init() {
 	registerBoth(&HitPush{})
 	registerBoth(&InitAI{})
 	registerBoth(&InitClient{})
-	registerBoth(&InitTokens{})
 	registerBoth(&InvisFlag{})
 	registerBoth(&ItemAck{})
 	registerBoth(&ItemList{})
@@ -1114,7 +1146,6 @@ func init() {
 	registerBoth(&Pong{})
 	registerBoth(&Pos{})
 	registerBoth(&RecordDemo{})
-	registerBoth(&ReplenishAmmo{})
 	registerBoth(&ReqAuth{})
 	registerBoth(&ResetFlag{})
 	registerBoth(&Resume{})
@@ -1140,7 +1171,6 @@ func init() {
 	registerBoth(&SwitchModel{})
 	registerBoth(&SwitchName{})
 	registerBoth(&SwitchTeam{})
-	registerBoth(&TakeToken{})
 	registerBoth(&Taunt{})
 	registerBoth(&TeamInfo{})
 	registerBoth(&Teleport{})
@@ -1149,11 +1179,21 @@ func init() {
 	registerBoth(&TryDropFlag{})
 	registerBoth(&TrySpawn{})
 	registerBoth(&Welcome{})
+	registerClient(&ClientBases{})
+	registerClient(&ClientDepositTokens{})
 	registerClient(&ClientInitFlags{})
+	registerClient(&ClientInitTokens{})
+	registerClient(&ClientReplenishAmmo{})
 	registerClient(&ClientTakeFlag{})
+	registerClient(&ClientTakeToken{})
 	registerClient(&SpawnRequest{})
+	registerServer(&Bases{})
+	registerServer(&DepositTokens{})
+	registerServer(&InitTokens{})
+	registerServer(&ReplenishAmmo{})
 	registerServer(&ServerInitFlags{})
 	registerServer(&ServerTakeFlag{})
+	registerServer(&TakeToken{})
 	registerServer(&SpawnResponse{})
 
 	// editing
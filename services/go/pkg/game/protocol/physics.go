@@ -14,7 +14,7 @@ type Vec struct {
 }
 
 func (v *Vec) SquaredLen() float64 {
-	return v.Z*v.Z + v.Z*v.Z + v.Z*v.Z
+	return v.X*v.X + v.Y*v.Y + v.Z*v.Z
 }
 
 func (v *Vec) Magnitude() float64 {
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/disconnectreason"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// Players that don't move, shoot or chat for AfkTimeout seconds are warned
+// and then moved to spectators, so they don't hold up their team. When the
+// server is almost full, spectators that have been idle for
+// AfkSpectatorTimeout seconds make room for players that want to join.
+
+const (
+	// how often we look for idle players
+	afkCheckInterval = 5 * time.Second
+	// how long before being moved to spectators players are warned
+	afkWarning = 15 * time.Second
+	// how many slots we try to keep free for new players
+	afkFreeSlots = 2
+)
+
+// Records that the client did something.
+func (c *Client) active() {
+	c.lastActive = time.Now()
+	c.afkWarned = false
+}
+
+func (c *Client) idle() time.Duration {
+	if c.lastActive.IsZero() {
+		return 0
+	}
+	return time.Since(c.lastActive)
+}
+
+// Warns and spectates idle players and disconnects idle spectators if the
+// server needs the room.
+func (s *Server) checkIdle() {
+	if s.Clock == nil || s.Clock.Paused() || s.Clock.Ended() {
+		return
+	}
+
+	timeout := time.Duration(s.AfkTimeout) * time.Second
+	warnAfter := timeout - afkWarning
+	if warnAfter < timeout/2 {
+		warnAfter = timeout / 2
+	}
+
+	var longestIdle *Client
+	s.Clients.ForEach(func(c *Client) {
+		if !c.Joined || c.IsBot() {
+			return
+		}
+
+		if c.State == playerstate.Spectator {
+			if c.Role == role.None && (longestIdle == nil || c.idle() > longestIdle.idle()) {
+				longestIdle = c
+			}
+			return
+		}
+
+		// nobody is holding anyone up in coop edit
+		if timeout <= 0 || s.GameMode.ID() == gamemode.CoopEdit {
+			return
+		}
+
+		idle := c.idle()
+		switch {
+		case idle >= timeout:
+			s.spectateIdle(c)
+		case idle >= warnAfter && !c.afkWarned:
+			c.afkWarned = true
+			c.Message(cubecode.Fail(fmt.Sprintf("you will be moved to spectators in %d seconds if you don't move", int((timeout-idle)/time.Second))))
+		}
+	})
+
+	if longestIdle != nil && s.needsRoom() && longestIdle.idle() >= time.Duration(s.AfkSpectatorTimeout)*time.Second {
+		s.disconnectIdle(longestIdle)
+	}
+}
+
+func (s *Server) spectateIdle(c *Client) {
+	// don't move them again right away if they rejoin
+	c.active()
+	s.SetSpectator(c, true)
+	s.Message(fmt.Sprintf("%s was moved to spectators for being inactive", s.Clients.UniqueName(c)))
+}
+
+// Whether idle spectators should make room for new players.
+func (s *Server) needsRoom() bool {
+	return s.AfkSpectatorTimeout > 0 && s.MaxClients > 0 && s.Clients.GetNumClients() >= s.MaxClients-afkFreeSlots
+}
+
+func (s *Server) disconnectIdle(c *Client) {
+	msg := fmt.Sprintf("%s was disconnected for being inactive", s.Clients.UniqueName(c))
+	s.Message(msg)
+	s.Disconnect(c, disconnectreason.Timeout)
+
+	s.Kicks.Publish(Kick{
+		SessionID: c.SessionID,
+		Reason:    disconnectreason.Timeout,
+		Message:   msg,
+	})
+}
@@ -4,10 +4,110 @@ import (
 	"fmt"
 	"log"
 
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/auth"
 	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
 	"github.com/cfoust/sour/pkg/server/protocol/role"
 )
 
+// Players claim privileges with the game's /auth and /sauth commands. The
+// client names a key with N_AUTHTRY (or N_AUTHKICK), we answer with a
+// challenge only the owner of the key can solve and grant them the key's role
+// once they send back the answer in N_AUTHANS.
+
+// findAuthKey looks for the key in the server's config first and then asks
+// FindAuthKey, if set.
+func (s *Server) findAuthKey(domain, name string) *AuthKey {
+	for _, key := range s.Config.AuthKeys {
+		if key.Domain == domain && key.Name == name {
+			key := key
+			return &key
+		}
+	}
+
+	if s.FindAuthKey != nil {
+		return s.FindAuthKey(domain, name)
+	}
+
+	return nil
+}
+
+// TryAuth starts authenticating c with the key called name in the given
+// domain. If victim is set, they are kicked once c is authenticated.
+func (s *Server) TryAuth(c *Client, domain, name string, victim *Client) {
+	key := s.findAuthKey(domain, name)
+	if key == nil {
+		c.Message(cubecode.Fail("unknown auth key"))
+		return
+	}
+
+	rol := role.Auth
+	if key.Role != "" {
+		rol = role.Parse(key.Role)
+	}
+	if rol <= role.None {
+		log.Printf("auth key %s [%s] has invalid role %q", name, domain, key.Role)
+		return
+	}
+
+	publicKey, err := auth.ParsePublicKey(key.Key)
+	if err != nil {
+		log.Printf("auth key %s [%s] is invalid: %s", name, domain, err)
+		c.Message(cubecode.Fail("authentication failed"))
+		return
+	}
+
+	challenge, err := auth.GenerateChallenge(publicKey)
+	if err != nil {
+		log.Println("failed to generate auth challenge:", err)
+		c.Message(cubecode.Fail("authentication failed"))
+		return
+	}
+
+	reqID := rng.Uint32()
+	c.Authentications[domain] = &Authentication{
+		reqID:     reqID,
+		name:      name,
+		role:      rol,
+		challenge: challenge,
+		victim:    victim,
+	}
+
+	c.Send(P.AuthChallenge{
+		Desc:      domain,
+		Id:        int32(reqID),
+		Challenge: challenge.Question,
+	})
+}
+
+// AnswerAuth checks c's answer to the challenge we sent for the given domain.
+func (s *Server) AnswerAuth(c *Client, domain string, reqID uint32, answer string) {
+	authentication, ok := c.Authentications[domain]
+	if !ok || authentication.challenge == nil || authentication.reqID != reqID {
+		return
+	}
+	challenge := authentication.challenge
+	authentication.challenge = nil
+
+	if !challenge.Check(answer) {
+		c.Message(cubecode.Fail("authentication failed"))
+		return
+	}
+
+	victim := authentication.victim
+	if victim != nil {
+		authentication.victim = nil
+		// the victim might have left while we waited for the answer
+		if s.Clients.GetClientByCN(victim.CN) != victim {
+			return
+		}
+		s.AuthKick(c, authentication.role, domain, authentication.name, victim, "")
+		return
+	}
+
+	s.setAuthRole(c, authentication.role, domain, authentication.name)
+}
+
 func (s *Server) setAuthRole(client *Client, rol role.ID, domain, name string) {
 	authUser := fmt.Sprintf("'%s'", cubecode.Magenta(name))
 	if domain != "" {
@@ -32,7 +132,7 @@ func (s *Server) setRole(client *Client, targetCN uint32, rol role.ID) {
 		client.Message(cubecode.Fail(fmt.Sprintf("no client with CN %d", targetCN)))
 		return
 	}
-	if target.Role == rol {
+	if target.Role == rol || target.IsBot() {
 		return
 	}
 	if client != target && client.Role <= target.Role || client == target && rol != role.None {
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Sauerbraten's auth keys are points on the NIST P-192 curve, written as a
+// sign for the parity of y followed by x in hex (see crypto.cpp in the game).
+// The game's /auth answers a challenge point by multiplying it with the
+// private key and sending back the x coordinate of the result.
+
+var p192 *elliptic.CurveParams
+
+func init() {
+	p192 = &elliptic.CurveParams{Name: "P-192", BitSize: 192}
+	p192.P, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffeffffffffffffffff", 16)
+	p192.N, _ = new(big.Int).SetString("ffffffffffffffffffffffff99def836146bc9b1b4d22831", 16)
+	p192.B, _ = new(big.Int).SetString("64210519e59c80e70fa7e9ab72243049feb8deecc146b9b1", 16)
+	p192.Gx, _ = new(big.Int).SetString("188da80eb03090f67cbf20eb43a18800f4ff0afd82ff1012", 16)
+	p192.Gy, _ = new(big.Int).SetString("07192b95ffc8da78631011ed6b24cdd573f977a11e794811", 16)
+}
+
+type PublicKey struct {
+	X, Y *big.Int
+}
+
+// ParsePublicKey parses a public key as generated by the game's /genauthkey.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	if len(s) < 2 || (s[0] != '+' && s[0] != '-') {
+		return nil, fmt.Errorf("invalid public key %q", s)
+	}
+
+	x, ok := new(big.Int).SetString(s[1:], 16)
+	if !ok || x.Cmp(p192.P) >= 0 {
+		return nil, fmt.Errorf("invalid public key %q", s)
+	}
+
+	// y² = x³ - 3x + b
+	three := big.NewInt(3)
+	y2 := new(big.Int).Exp(x, three, p192.P)
+	y2.Sub(y2, new(big.Int).Mul(x, three))
+	y2.Add(y2, p192.B)
+	y2.Mod(y2, p192.P)
+
+	// p ≡ 3 (mod 4), so the square root is y2^((p+1)/4)
+	exp := new(big.Int).Add(p192.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(y2, exp, p192.P)
+	if new(big.Int).Exp(y, big.NewInt(2), p192.P).Cmp(y2) != 0 {
+		return nil, fmt.Errorf("public key %q is not on the curve", s)
+	}
+
+	odd := y.Bit(0) == 1
+	if odd != (s[0] == '-') {
+		y.Sub(p192.P, y)
+	}
+
+	return &PublicKey{X: x, Y: y}, nil
+}
+
+// like bigint::printdigits in the game: hex, in groups of four digits
+func printDigits(n *big.Int) string {
+	digits := n.Text(16)
+	if pad := len(digits) % 4; pad != 0 {
+		digits = strings.Repeat("0", 4-pad) + digits
+	}
+	return digits
+}
+
+type Challenge struct {
+	// what we send to the client in N_AUTHCHAL
+	Question string
+	answer   *big.Int
+}
+
+// GenerateChallenge creates a challenge only the owner of the private key
+// that belongs to key can answer.
+func GenerateChallenge(key *PublicKey) (*Challenge, error) {
+	secret, err := rand.Int(rand.Reader, new(big.Int).Sub(p192.N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	secret.Add(secret, big.NewInt(1))
+
+	return newChallenge(key, secret), nil
+}
+
+// Creates the challenge for the given secret, a number between 1 and the
+// order of the curve.
+func newChallenge(key *PublicKey, secret *big.Int) *Challenge {
+	qx, qy := p192.ScalarBaseMult(secret.Bytes())
+	sign := "+"
+	if qy.Bit(0) != 0 {
+		sign = "-"
+	}
+
+	// the client calculates private * secret * G, which is the same as
+	// secret * public
+	ax, _ := p192.ScalarMult(key.X, key.Y, secret.Bytes())
+
+	return &Challenge{
+		Question: sign + printDigits(qx),
+		answer:   ax,
+	}
+}
+
+// Check returns whether the client's answer to the challenge is correct.
+func (c *Challenge) Check(answer string) bool {
+	value, ok := new(big.Int).SetString(answer, 16)
+	return ok && value.Cmp(c.answer) == 0
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generated with "/genauthkey sour test key" in the game
+const (
+	testPrivateKey = "d6cf21517a5c7bad832c84dd62aea6033e2fda69fb65c7c0"
+	testPublicKey  = "+3dada9d8159f0e6b284d6cba43f516d085bcb2df59d3a02b"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	private, _ := new(big.Int).SetString(testPrivateKey, 16)
+	x, y := p192.ScalarBaseMult(private.Bytes())
+
+	key, err := ParsePublicKey(testPublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, x, key.X)
+	assert.Equal(t, y, key.Y)
+
+	// the same point with the other y
+	key, err = ParsePublicKey("-" + testPublicKey[1:])
+	assert.NoError(t, err)
+	assert.Equal(t, x, key.X)
+	assert.Equal(t, new(big.Int).Sub(p192.P, y), key.Y)
+
+	for _, invalid := range []string{
+		"",
+		"+",
+		"3dada9d8159f0e6b284d6cba43f516d085bcb2df59d3a02b",
+		"+not hex",
+		// x is larger than the field
+		"+ffffffffffffffffffffffffffffffffffffffffffffffff",
+		// no point on the curve has this x
+		"+1",
+	} {
+		_, err := ParsePublicKey(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	key, err := ParsePublicKey(testPublicKey)
+	assert.NoError(t, err)
+
+	// questions and the answers the game's /auth gives for them with the
+	// test key
+	for _, test := range []struct {
+		secret   string
+		question string
+		answer   string
+	}{
+		{
+			"1",
+			"-188da80eb03090f67cbf20eb43a18800f4ff0afd82ff1012",
+			"3dada9d8159f0e6b284d6cba43f516d085bcb2df59d3a02b",
+		},
+		{
+			"2a",
+			"+aab25e81017d5ddf06d79efa5bf69430decbb763e07766a0",
+			"e4a697645d26736ddf28411640c08fb3718a4905ff7bda8b",
+		},
+		{
+			"123456789abcdef0123456789abcdef0123456789abcde",
+			"-977aee0d86fc9c462191646a223bb57899d35d2c761b8aeb",
+			"33e5b346a72ad91fde2cdaa0592e643636f3bb2aa2558b1e",
+		},
+	} {
+		secret, _ := new(big.Int).SetString(test.secret, 16)
+		challenge := newChallenge(key, secret)
+		assert.Equal(t, test.question, challenge.Question)
+		assert.True(t, challenge.Check(test.answer))
+		assert.False(t, challenge.Check(test.answer[1:]))
+		assert.False(t, challenge.Check("not hex"))
+	}
+
+	challenge, err := GenerateChallenge(key)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, challenge.Question)
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// Clients don't send server passwords in the clear: they hash them together
+// with the client number and session ID the server gave them (see
+// hashpassword() in the game).
+
+// HashPassword hashes a password like a client with the given client number
+// and session ID does before sending it.
+func HashPassword(cn, sessionID int32, password string) string {
+	sum := tigerSum([]byte(fmt.Sprintf("%d %d %s", cn, sessionID, password)))
+
+	// the game writes the low nibble of each byte first
+	const digits = "0123456789abcdef"
+	hash := make([]byte, 0, 2*len(sum))
+	for _, b := range sum {
+		hash = append(hash, digits[b&0xf], digits[b>>4])
+	}
+	return string(hash)
+}
+
+// CheckPassword returns whether hash is what a client with the given client
+// number and session ID sends for password.
+func CheckPassword(cn, sessionID int32, password, hash string) bool {
+	expected := HashPassword(cn, sessionID, password)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	// what the game sends for these passwords
+	for _, test := range []struct {
+		cn        int32
+		sessionID int32
+		password  string
+		hash      string
+	}{
+		{0, 0, "", "e1c592ad9895ddba5f024526a7bda72f550218c932c56955"},
+		{3, 12345, "hunter2", "96158133a316cec492609f42a7e0d2125c905b8d06b3a7a4"},
+		{127, -5, "pass word", "0192077099435bf4441480a3c02ae8d858f0ae1c40dadcb1"},
+	} {
+		assert.Equal(t, test.hash, HashPassword(test.cn, test.sessionID, test.password))
+		assert.True(t, CheckPassword(test.cn, test.sessionID, test.password, test.hash))
+		assert.False(t, CheckPassword(test.cn+1, test.sessionID, test.password, test.hash))
+		assert.False(t, CheckPassword(test.cn, test.sessionID, test.password+"x", test.hash))
+	}
+}
+
+func TestTigerSum(t *testing.T) {
+	// the reference vectors, which also cover inputs that take more than
+	// one block
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+-"
+	for _, test := range []struct {
+		input string
+		sum   string
+	}{
+		{"", "3293ac630c13f0245f92bbb1766e16167a4e58492dde73f3"},
+		{"abc", "2aab1484e8c158f2bfb8c5ff41b57a525129131c957b5f93"},
+		{"Tiger - A Fast New Hash Function, by Ross Anderson and Eli Biham", "8a866829040a410c729ad23f5ada711603b3cdd357e4c15e"},
+		{alphabet + alphabet, "c54034e5b43eb8005848a7e0ae6aac76e4ff590ae715fd25"},
+	} {
+		sum := tigerSum([]byte(test.input))
+		assert.Equal(t, test.sum, hex.EncodeToString(sum[:]), test.input)
+	}
+}
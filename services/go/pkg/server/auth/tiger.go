@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"encoding/binary"
+)
+
+// The game hashes passwords with Tiger, the original variant that pads
+// messages with 0x01 (see tiger.cpp in the game). Instead of spelling out
+// the S-boxes, we generate them the way their authors did.
+
+var tigerTable [4 * 256]uint64
+
+var tigerIV = [3]uint64{0x0123456789ABCDEF, 0xFEDCBA9876543210, 0xF096A5B4C3B2E187}
+
+func init() {
+	generateTigerTable()
+}
+
+func tigerRound(a, b, c *uint64, x, mul uint64) {
+	*c ^= x
+	t := *c
+	*a -= tigerTable[t&0xff] ^
+		tigerTable[256+(t>>16)&0xff] ^
+		tigerTable[512+(t>>32)&0xff] ^
+		tigerTable[768+(t>>48)&0xff]
+	*b += tigerTable[768+(t>>8)&0xff] ^
+		tigerTable[512+(t>>24)&0xff] ^
+		tigerTable[256+(t>>40)&0xff] ^
+		tigerTable[(t>>56)&0xff]
+	*b *= mul
+}
+
+func tigerPass(a, b, c *uint64, x *[8]uint64, mul uint64) {
+	tigerRound(a, b, c, x[0], mul)
+	tigerRound(b, c, a, x[1], mul)
+	tigerRound(c, a, b, x[2], mul)
+	tigerRound(a, b, c, x[3], mul)
+	tigerRound(b, c, a, x[4], mul)
+	tigerRound(c, a, b, x[5], mul)
+	tigerRound(a, b, c, x[6], mul)
+	tigerRound(b, c, a, x[7], mul)
+}
+
+func tigerKeySchedule(x *[8]uint64) {
+	x[0] -= x[7] ^ 0xA5A5A5A5A5A5A5A5
+	x[1] ^= x[0]
+	x[2] += x[1]
+	x[3] -= x[2] ^ (^x[1] << 19)
+	x[4] ^= x[3]
+	x[5] += x[4]
+	x[6] -= x[5] ^ (^x[4] >> 23)
+	x[7] ^= x[6]
+	x[0] += x[7]
+	x[1] -= x[0] ^ (^x[7] << 19)
+	x[2] ^= x[1]
+	x[3] += x[2]
+	x[4] -= x[3] ^ (^x[2] >> 23)
+	x[5] ^= x[4]
+	x[6] += x[5]
+	x[7] -= x[6] ^ 0x0123456789ABCDEF
+}
+
+// Mixes a 64 byte block into the state.
+func tigerCompress(block []byte, state *[3]uint64) {
+	var x [8]uint64
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint64(block[i*8:])
+	}
+
+	a, b, c := state[0], state[1], state[2]
+	tigerPass(&a, &b, &c, &x, 5)
+	tigerKeySchedule(&x)
+	tigerPass(&c, &a, &b, &x, 7)
+	tigerKeySchedule(&x)
+	tigerPass(&b, &c, &a, &x, 9)
+
+	state[0] = a ^ state[0]
+	state[1] = b - state[1]
+	state[2] = c + state[2]
+}
+
+func tigerTableByte(i, col int) byte {
+	return byte(tigerTable[i] >> (8 * col))
+}
+
+func setTigerTableByte(i, col int, value byte) {
+	shift := 8 * col
+	tigerTable[i] = tigerTable[i]&^(0xff<<shift) | uint64(value)<<shift
+}
+
+// Starts out with the identity permutation in every byte column of the
+// S-boxes and shuffles it with the output of the hash itself, which uses the
+// S-boxes as they are being generated.
+func generateTigerTable() {
+	seed := []byte("Tiger - A Fast New Hash Function, by Ross Anderson and Eli Biham")
+
+	for i := range tigerTable {
+		for col := 0; col < 8; col++ {
+			setTigerTableByte(i, col, byte(i))
+		}
+	}
+
+	state := tigerIV
+	abc := 2
+	for pass := 0; pass < 5; pass++ {
+		for i := 0; i < 256; i++ {
+			for sbox := 0; sbox < len(tigerTable); sbox += 256 {
+				abc++
+				if abc == 3 {
+					abc = 0
+					tigerCompress(seed, &state)
+				}
+				for col := 0; col < 8; col++ {
+					j := sbox + int(byte(state[abc]>>(8*col)))
+					swapped := tigerTableByte(sbox+i, col)
+					setTigerTableByte(sbox+i, col, tigerTableByte(j, col))
+					setTigerTableByte(j, col, swapped)
+				}
+			}
+		}
+	}
+}
+
+func tigerSum(data []byte) [24]byte {
+	state := tigerIV
+	length := uint64(len(data))
+
+	for len(data) >= 64 {
+		tigerCompress(data[:64], &state)
+		data = data[64:]
+	}
+
+	last := make([]byte, 0, 128)
+	last = append(last, data...)
+	last = append(last, 0x01)
+	for len(last)%64 != 56 {
+		last = append(last, 0)
+	}
+	var bits [8]byte
+	binary.LittleEndian.PutUint64(bits[:], length*8)
+	last = append(last, bits[:]...)
+	for ; len(last) > 0; last = last[64:] {
+		tigerCompress(last[:64], &state)
+	}
+
+	var sum [24]byte
+	for i, word := range state {
+		binary.LittleEndian.PutUint64(sum[i*8:], word)
+	}
+	return sum
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// When BalanceTeams is enabled, the server moves players from the biggest to
+// the smallest team whenever a team has two players more than another one,
+// for example because players left. It picks the player whose move evens out
+// the skill of the teams best, which means frags or, if the cluster passes in
+// ratings through Rating and all players have one, their rating. At
+// intermission, it suggests swaps that would make the teams more even.
+// Masters can lock the teams, which stops both the balancing and players
+// switching teams on their own.
+
+// how much more skilled a team has to be for us to suggest a swap, in percent
+const swapThreshold = 20
+
+// How good each player is: their rating, if the cluster passes in ratings
+// through Rating and knows every one of the players, or their frags. Ratings
+// and frags are on different scales, so we never mix them.
+func (s *Server) skills(members map[*game.Team][]*Client) map[*Client]int {
+	frags := map[*Client]int{}
+	ratings := map[*Client]int{}
+	for _, team := range members {
+		for _, c := range team {
+			frags[c] = int(c.Frags)
+			if s.Rating == nil || c.IsBot() {
+				continue
+			}
+			if rating, ok := s.Rating(c.SessionID); ok {
+				ratings[c] = rating
+			}
+		}
+	}
+	if len(ratings) < len(frags) {
+		return frags
+	}
+	return ratings
+}
+
+// Whether c plays for their team. Players eliminated from the current round
+// watch as spectators, but still play for their team.
+func (s *Server) playsForTeam(c *Client) bool {
+	if !c.Joined || c.Team == game.NoTeam {
+		return false
+	}
+	if c.State != playerstate.Spectator {
+		return true
+	}
+	rounds, ok := s.GameMode.(game.RoundMode)
+	return ok && rounds.Eliminated(&c.Player)
+}
+
+// Returns the players of each team.
+func (s *Server) teamMembers() map[*game.Team][]*Client {
+	members := map[*game.Team][]*Client{}
+	s.Clients.ForEach(func(c *Client) {
+		if !s.playsForTeam(c) {
+			return
+		}
+		members[c.Team] = append(members[c.Team], c)
+	})
+	return members
+}
+
+func teamSkill(members []*Client, skills map[*Client]int) (sum int) {
+	for _, c := range members {
+		sum += skills[c]
+	}
+	return
+}
+
+// Picks the player of big whose move to small evens out the teams the most.
+// Bots are moved before humans, and dead players before living ones, since
+// changing teams kills them.
+func pickTeamChange(big, small []*Client, skills map[*Client]int) *Client {
+	bigSkill, smallSkill := teamSkill(big, skills), teamSkill(small, skills)
+
+	var best *Client
+	bestScore := 0
+	for _, c := range big {
+		skill := skills[c]
+		difference := (bigSkill - skill) - (smallSkill + skill)
+		if difference < 0 {
+			difference = -difference
+		}
+
+		score := difference
+		if !c.IsBot() {
+			score += 1 << 20
+		}
+		if c.State == playerstate.Alive {
+			score += 1 << 10
+		}
+
+		if best == nil || score < bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// BalanceTeams moves players until no team has two or more players than
+// another one.
+func (s *Server) BalanceTeams() {
+	teamMode, ok := s.GameMode.(game.TeamMode)
+	if !s.Config.BalanceTeams || s.TeamsLocked || !ok || s.Clock.Ended() {
+		return
+	}
+
+	for {
+		members := s.teamMembers()
+
+		teams := []*game.Team{}
+		teamMode.ForEachTeam(func(t *game.Team) {
+			teams = append(teams, t)
+		})
+		if len(teams) < 2 {
+			return
+		}
+		sort.Slice(teams, func(i, j int) bool {
+			return len(members[teams[i]]) < len(members[teams[j]])
+		})
+
+		small, big := teams[0], teams[len(teams)-1]
+		if len(members[big])-len(members[small]) < 2 {
+			return
+		}
+
+		c := pickTeamChange(members[big], members[small], s.skills(members))
+		teamMode.ChangeTeam(&c.Player, small.Name, true)
+		if c.Team != small {
+			return
+		}
+		if !c.IsBot() {
+			s.Message(fmt.Sprintf("%s was moved to team %s to balance the teams", s.Clients.UniqueName(c), small.Name))
+		}
+	}
+}
+
+// Picks two human players of two teams whose swap would even out the teams
+// the most, if one team is so much stronger that it's worth it.
+func pickSwap(one, other []*Client, skills map[*Client]int) (first, second *Client) {
+	strong, weak := one, other
+	strongSkill, weakSkill := teamSkill(strong, skills), teamSkill(weak, skills)
+	if strongSkill < weakSkill {
+		strong, weak = weak, strong
+		strongSkill, weakSkill = weakSkill, strongSkill
+	}
+	if strongSkill <= 0 || (strongSkill-weakSkill)*100 < strongSkill*swapThreshold {
+		return nil, nil
+	}
+
+	best := strongSkill - weakSkill
+	for _, a := range strong {
+		for _, b := range weak {
+			if a.IsBot() || b.IsBot() {
+				continue
+			}
+			shift := skills[a] - skills[b]
+			difference := (strongSkill - shift) - (weakSkill + shift)
+			if difference < 0 {
+				difference = -difference
+			}
+			if difference < best {
+				first, second, best = a, b, difference
+			}
+		}
+	}
+	return first, second
+}
+
+// Suggests a swap of two players that would even out the skill of two teams.
+func (s *Server) suggestSwap() {
+	teamMode, ok := s.GameMode.(game.TeamMode)
+	if !s.Config.BalanceTeams || !ok {
+		return
+	}
+
+	members := s.teamMembers()
+	teams := []*game.Team{}
+	teamMode.ForEachTeam(func(t *game.Team) {
+		teams = append(teams, t)
+	})
+	if len(teams) != 2 {
+		return
+	}
+
+	first, second := pickSwap(members[teams[0]], members[teams[1]], s.skills(members))
+	if first == nil {
+		return
+	}
+
+	s.Message(fmt.Sprintf(
+		"the teams are uneven, %s and %s could swap teams",
+		s.Clients.UniqueName(first),
+		s.Clients.UniqueName(second),
+	))
+}
+
+// Whether c may switch to the given team on their own.
+func (s *Server) canSwitchTeam(c *Client, team string) bool {
+	if s.TeamsLocked {
+		c.Message(cubecode.Fail("teams are locked"))
+		return false
+	}
+
+	teamMode, ok := s.GameMode.(game.TeamMode)
+	if !s.Config.BalanceTeams || !ok || !s.playsForTeam(c) {
+		return true
+	}
+
+	members := s.teamMembers()
+	target, ok := teamMode.Teams()[team]
+	// whether players may make up new teams is up to the mode
+	if !ok {
+		return true
+	}
+	if len(members[target]) >= len(members[c.Team]) {
+		c.Message(cubecode.Fail(fmt.Sprintf("team %s already has enough players", team)))
+		return false
+	}
+	return true
+}
+
+// LockTeams stops the server from moving players between teams and players
+// from switching teams on their own.
+func (s *Server) LockTeams(c *Client, locked bool) {
+	if c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+	if s.ClanWar && !locked {
+		c.Message(cubecode.Fail("teams stay locked during a clan war"))
+		return
+	}
+
+	s.TeamsLocked = locked
+	if locked {
+		s.Message(fmt.Sprintf("%s locked the teams", s.Clients.UniqueName(c)))
+		return
+	}
+	s.Message(fmt.Sprintf("%s unlocked the teams", s.Clients.UniqueName(c)))
+	s.BalanceTeams()
+}
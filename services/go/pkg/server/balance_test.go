@@ -0,0 +1,208 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(sessionID uint32, frags int32) *Client {
+	c := &Client{SessionID: sessionID}
+	c.Frags = frags
+	c.State = playerstate.Dead
+	return c
+}
+
+func TestSkills(t *testing.T) {
+	a, b, c := newTestClient(1, 10), newTestClient(2, 20), newTestClient(3, 30)
+	bot := newTestClient(4, 40)
+	bot.ai = newBotAI()
+
+	for _, test := range []struct {
+		name    string
+		ratings map[uint32]int
+		members []*Client
+		want    map[*Client]int
+	}{
+		{
+			name:    "no ratings",
+			members: []*Client{a, b},
+			want:    map[*Client]int{a: 10, b: 20},
+		},
+		{
+			name:    "all rated",
+			ratings: map[uint32]int{1: 1200, 2: 1100},
+			members: []*Client{a, b},
+			want:    map[*Client]int{a: 1200, b: 1100},
+		},
+		{
+			name:    "some rated",
+			ratings: map[uint32]int{1: 1200, 2: 1100},
+			members: []*Client{a, b, c},
+			want:    map[*Client]int{a: 10, b: 20, c: 30},
+		},
+		{
+			name:    "bots have no rating",
+			ratings: map[uint32]int{1: 1200, 4: 1100},
+			members: []*Client{a, bot},
+			want:    map[*Client]int{a: 10, bot: 40},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{}
+			if test.ratings != nil {
+				s.Rating = func(sessionID uint32) (int, bool) {
+					rating, ok := test.ratings[sessionID]
+					return rating, ok
+				}
+			}
+
+			team := &game.Team{Name: "good"}
+			skills := s.skills(map[*game.Team][]*Client{team: test.members})
+			assert.Equal(t, test.want, skills)
+		})
+	}
+}
+
+func TestPickTeamChange(t *testing.T) {
+	weak, strong := newTestClient(1, 2), newTestClient(2, 10)
+	other := newTestClient(3, 6)
+	alive := newTestClient(4, 2)
+	alive.State = playerstate.Alive
+	bot := newTestClient(5, 10)
+	bot.ai = newBotAI()
+
+	for _, test := range []struct {
+		name       string
+		big, small []*Client
+		want       *Client
+	}{
+		{
+			name:  "evens out the teams",
+			big:   []*Client{weak, strong, other},
+			small: []*Client{newTestClient(6, 0)},
+			want:  strong,
+		},
+		{
+			name:  "moves the weakest to a strong team",
+			big:   []*Client{weak, strong, other},
+			small: []*Client{newTestClient(6, 30)},
+			want:  weak,
+		},
+		{
+			name:  "moves bots first",
+			big:   []*Client{weak, strong, bot},
+			small: []*Client{newTestClient(6, 0)},
+			want:  bot,
+		},
+		{
+			name:  "moves dead players first",
+			big:   []*Client{alive, strong, other},
+			small: []*Client{newTestClient(6, 30)},
+			want:  other,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			skills := map[*Client]int{}
+			for _, c := range append(test.big, test.small...) {
+				skills[c] = int(c.Frags)
+			}
+			assert.Equal(t, test.want, pickTeamChange(test.big, test.small, skills))
+		})
+	}
+}
+
+func TestPickSwap(t *testing.T) {
+	a, b := newTestClient(1, 20), newTestClient(2, 10)
+	c, d := newTestClient(3, 5), newTestClient(4, 4)
+	bot := newTestClient(5, 20)
+	bot.ai = newBotAI()
+
+	for _, test := range []struct {
+		name          string
+		one, other    []*Client
+		first, second *Client
+	}{
+		{
+			name:   "swaps to even out the teams",
+			one:    []*Client{a, b, newTestClient(6, 2)},
+			other:  []*Client{d},
+			first:  a,
+			second: d,
+		},
+		{
+			name:  "even teams",
+			one:   []*Client{a, c},
+			other: []*Client{b, newTestClient(6, 15)},
+		},
+		{
+			name:  "nobody scored",
+			one:   []*Client{newTestClient(6, 0)},
+			other: []*Client{newTestClient(7, 0)},
+		},
+		{
+			name:  "bots aren't swapped",
+			one:   []*Client{bot},
+			other: []*Client{c},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			skills := map[*Client]int{}
+			for _, c := range append(test.one, test.other...) {
+				skills[c] = int(c.Frags)
+			}
+			first, second := pickSwap(test.one, test.other, skills)
+			assert.Equal(t, test.first, first)
+			assert.Equal(t, test.second, second)
+		})
+	}
+}
+
+// A round mode in which the players in eliminated are out of the round.
+type testRounds struct {
+	game.RoundMode
+	eliminated map[*game.Player]bool
+}
+
+func (m testRounds) Eliminated(p *game.Player) bool {
+	return m.eliminated[p]
+}
+
+func TestTeamMembers(t *testing.T) {
+	good := &game.Team{Name: "good"}
+
+	s := &Server{State: &State{}, Clients: &ClientManager{}}
+	player := s.Clients.Add(1, nil)
+	eliminated := s.Clients.Add(2, nil)
+	spectator := s.Clients.Add(3, nil)
+	for _, c := range []*Client{player, eliminated, spectator} {
+		c.Joined = true
+		c.Team = good
+		c.State = playerstate.Spectator
+	}
+	player.State = playerstate.Alive
+
+	for _, test := range []struct {
+		name string
+		mode game.Mode
+		want []*Client
+	}{
+		{
+			name: "spectators don't play",
+			mode: testRounds{},
+			want: []*Client{player},
+		},
+		{
+			name: "eliminated players still play",
+			mode: testRounds{eliminated: map[*game.Player]bool{&eliminated.Player: true}},
+			want: []*Client{player, eliminated},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s.GameMode = test.mode
+			assert.Equal(t, map[*game.Team][]*Client{good: test.want}, s.teamMembers())
+		})
+	}
+}
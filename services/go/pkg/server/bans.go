@@ -0,0 +1,50 @@
+package server
+
+import (
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/disconnectreason"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// The server does not know where its clients connect from, so it leaves
+// enforcing bans to whoever runs it: kicks and requests to clear bans are
+// published on Kicks and BansCleared.
+
+// A Kick is sent when a client was kicked from the server.
+type Kick struct {
+	SessionID uint32
+	Reason    disconnectreason.ID
+	Message   string
+	// How long the client should be banned for, 0 if they shouldn't be
+	Ban time.Duration
+}
+
+// Removes the client from the game and tells the owner of the server to
+// disconnect them, banning them if the server is configured to do so.
+func (s *Server) kick(victim *Client, message string) {
+	s.Disconnect(victim, disconnectreason.Kick)
+
+	if victim.IsBot() {
+		return
+	}
+
+	s.Kicks.Publish(Kick{
+		SessionID: victim.SessionID,
+		Reason:    disconnectreason.Kick,
+		Message:   message,
+		Ban:       time.Duration(s.Config.KickBanDuration) * time.Second,
+	})
+}
+
+// ClearBans lifts the bans that resulted from kicks on this server.
+func (s *Server) ClearBans(c *Client) {
+	if c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	s.BansCleared.Publish(struct{}{})
+	s.Message("cleared all bans")
+}
@@ -0,0 +1,472 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/disconnectreason"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// Bots are players with CNs of their own that the server runs. Their AI runs
+// in the server loop: it gets the messages the server sends to the bot and
+// sends the bot's own messages through HandlePacket, just like vanilla clients
+// send the messages of the bots they run after N_FROMAI. Clients are told
+// that every bot runs itself, so they show bots where we say they are.
+//
+// Bots walk the map along the waypoints players leave behind (see
+// navigation.go), picking up items and using teleports on the way. Without
+// the map's geometry the server can't tell whom a bot can see, so bots don't
+// fight back.
+
+// values taken from aiman.h in the vanilla game
+const (
+	maxBots         = 32
+	defaultBotLimit = 8
+	botMinSkill     = 50
+	botMaxSkill     = 80
+	aiTypeBot       = 1
+)
+
+const (
+	// about as often as clients send their position
+	botThinkInterval = 33 * time.Millisecond
+	// how long dead bots wait before they respawn
+	botRespawnDelay = 2 * time.Second
+	// how long bots that don't know where to go wait before trying again
+	botPlanDelay = time.Second
+)
+
+// new bots are named after the first of these no one uses
+var botNames = []string{
+	"Ash", "Bishop", "Bolt", "Cobalt", "Crow", "Dagger", "Dusk", "Ember",
+	"Flint", "Frost", "Ghost", "Grim", "Havoc", "Hex", "Iris", "Jinx",
+	"Kestrel", "Lynx", "Mako", "Nova", "Onyx", "Pike", "Quill", "Raven",
+	"Sable", "Talon", "Umbra", "Viper", "Wren", "Xeno", "Yeti", "Zephyr",
+}
+
+func botInitPacket(bot *Client) P.InitAI {
+	return P.InitAI{
+		Aiclientnum:    int32(bot.CN),
+		Ownerclientnum: int32(bot.CN),
+		Aitype:         aiTypeBot,
+		Aiskill:        bot.Skill,
+		Playermodel:    bot.Model,
+		Name:           bot.Name,
+		Team:           bot.Team.Name,
+	}
+}
+
+type botAI struct {
+	// set once the server told the bot to spawn
+	spawning bool
+
+	position *geom.Vector
+	yaw      float64
+
+	// the navigation the bot found its way with, which changes with the map
+	nav *navigation
+	// the waypoint the bot was at last, -1 if none
+	at       int
+	path     []step
+	nextPlan time.Time
+
+	lastThink time.Time
+}
+
+func newBotAI() *botAI {
+	return &botAI{at: -1}
+}
+
+// receive handles the messages the server sends to the bot.
+func (ai *botAI) receive(messages ...P.Message) {
+	for _, message := range messages {
+		if _, ok := message.(P.SpawnState); ok {
+			ai.spawning = true
+		}
+	}
+}
+
+func (ai *botAI) think(s *Server, bot *Client) {
+	now := time.Now()
+	var elapsed time.Duration
+	if !ai.lastThink.IsZero() {
+		elapsed = now.Sub(ai.lastThink)
+	}
+	ai.lastThink = now
+
+	if ai.nav != s.nav {
+		ai.nav = s.nav
+		ai.at = -1
+		ai.path = nil
+	}
+
+	if ai.spawning {
+		ai.spawn(s, bot)
+		return
+	}
+
+	switch bot.State {
+	case playerstate.Dead:
+		if bot.LastSpawnAttempt.IsZero() && now.Sub(bot.LastDeath) >= botRespawnDelay {
+			s.HandlePacket(bot, 1, P.TrySpawn{})
+		}
+	case playerstate.Alive:
+		ai.walk(s, bot, elapsed)
+	}
+}
+
+// spawn puts the bot on one of the map's player starts and confirms the spawn,
+// like clients do once they are told to spawn. Bots wait until the server
+// knows the map's entities.
+func (ai *botAI) spawn(s *Server, bot *Client) {
+	start, ok := s.nav.spawnPoint(bot.Team.Name, s.rng)
+	if !ok {
+		return
+	}
+
+	ai.spawning = false
+	ai.position = standingOn(start)
+	ai.yaw = float64(start.Attr1)
+	ai.at = s.nav.nearest(ai.position, entityRadius)
+	ai.path = nil
+	ai.nextPlan = time.Time{}
+
+	s.HandlePacket(bot, 1, P.SpawnRequest{
+		LifeSequence: bot.LifeSequence,
+		GunSelect:    int32(bot.SelectedWeapon.ID),
+	})
+	ai.sendPosition(s, bot, nil)
+}
+
+// The items that can be picked up right now.
+func spawnedItems(s *Server) []P.Item {
+	pickupMode, ok := s.GameMode.(game.PickupMode)
+	if !ok {
+		return nil
+	}
+	items, _ := pickupMode.PickupsInitPacket().(P.ItemList)
+	return items.Items
+}
+
+// plan picks where the bot goes next: to one of the items lying around or one
+// of the player starts, where other players show up. If the bot can't get to
+// any of them, it goes anywhere players went from where it is.
+func (ai *botAI) plan(s *Server) {
+	if time.Now().Before(ai.nextPlan) {
+		return
+	}
+	ai.nextPlan = time.Now().Add(botPlanDelay)
+
+	if ai.at < 0 {
+		ai.at = s.nav.nearest(ai.position, entityRadius)
+		if ai.at < 0 {
+			return
+		}
+	}
+
+	routes := s.nav.routes(ai.at)
+	reachable := func(to int) bool {
+		return to >= 0 && to != ai.at && !math.IsInf(routes[to].distance, 1)
+	}
+
+	goals := []int{}
+	entities := append([]MapEntity{}, s.nav.starts...)
+	for _, item := range spawnedItems(s) {
+		if e, ok := s.nav.pickups[item.Index]; ok {
+			entities = append(entities, e)
+		}
+	}
+	for _, e := range entities {
+		if to := s.nav.nearest(standingOn(e), entityRadius); reachable(to) {
+			goals = append(goals, to)
+		}
+	}
+	if len(goals) == 0 {
+		for to := range routes {
+			if reachable(to) {
+				goals = append(goals, to)
+			}
+		}
+	}
+	if len(goals) == 0 {
+		return
+	}
+
+	ai.path = path(routes, goals[s.rng.Intn(len(goals))])
+}
+
+// walk moves the bot along its path as far as it gets in the time that
+// elapsed.
+func (ai *botAI) walk(s *Server, bot *Client, elapsed time.Duration) {
+	if len(ai.path) == 0 {
+		ai.plan(s)
+	}
+
+	speed := maxPlayerSpeed * float64(s.Speed) / defaultGameSpeed
+	left := speed * elapsed.Seconds()
+	var velocity *geom.Vector
+	for left > 0 && len(ai.path) > 0 {
+		next := &ai.path[0]
+		if next.teleport != nil {
+			ai.position = standingOn(*next.teledest)
+			ai.yaw = float64(next.teledest.Attr1)
+			s.HandlePacket(bot, 0, P.Teleport{
+				Client:      int32(bot.CN),
+				Source:      next.teleport.Index,
+				Destination: next.teledest.Index,
+			})
+			// walk on to the waypoint next to the destination
+			next.teleport, next.teledest = nil, nil
+			continue
+		}
+
+		target := s.nav.waypoints[next.waypoint].position
+		way := target.Sub(ai.position)
+		distance := way.Magnitude()
+		if distance > 0 {
+			velocity = way.Scale(speed)
+		}
+		if distance > left {
+			ai.position = ai.position.Add(way.Scale(left))
+			break
+		}
+
+		ai.position = target
+		ai.at = next.waypoint
+		ai.path = ai.path[1:]
+		left -= distance
+	}
+
+	for _, item := range spawnedItems(s) {
+		e, ok := s.nav.pickups[item.Index]
+		if ok && geom.Distance(standingOn(e), ai.position) <= pickupRadius {
+			s.HandlePacket(bot, 1, P.ItemPickup{Item: item.Index})
+		}
+	}
+
+	ai.sendPosition(s, bot, velocity)
+}
+
+// sendPosition tells everyone where the bot is. Bots send their position even
+// when they stand still, since clients show players they didn't hear from for
+// a while as lagging.
+func (ai *botAI) sendPosition(s *Server, bot *Client, velocity *geom.Vector) {
+	state := P.PhysicsState{
+		State: physFloor,
+		O:     P.Vec{X: ai.position.X(), Y: ai.position.Y(), Z: ai.position.Z()},
+	}
+	if velocity != nil {
+		// like in vanilla, a yaw of 0 faces along the Y axis
+		if velocity.X() != 0 || velocity.Y() != 0 {
+			ai.yaw = math.Mod(360-math.Atan2(velocity.X(), velocity.Y())*180/math.Pi, 360)
+		}
+		state.Move = 1
+		state.Velocity = P.Vec{X: velocity.X(), Y: velocity.Y(), Z: velocity.Z()}
+	}
+	state.Yaw = ai.yaw
+
+	s.HandlePacket(bot, 0, P.Pos{Client: int32(bot.CN), State: state})
+}
+
+// runBots lets all bots act, unless the game is paused or over.
+func (s *Server) runBots() {
+	frozen := s.Clock == nil || s.Clock.Paused() || s.Clock.Ended()
+	for _, bot := range s.Clients.Bots() {
+		if frozen {
+			bot.ai.lastThink = time.Time{}
+			continue
+		}
+		bot.ai.think(s, bot)
+	}
+}
+
+// botName picks a name for a new bot that no one else has.
+func (s *Server) botName() string {
+	taken := map[string]bool{}
+	s.Clients.ForEach(func(c *Client) {
+		taken[c.Name] = true
+	})
+
+	free := []string{}
+	for _, name := range botNames {
+		if !taken[name] {
+			free = append(free, name)
+		}
+	}
+	if len(free) == 0 {
+		return "bot"
+	}
+	return free[s.rng.Intn(len(free))]
+}
+
+// AddBot adds a bot with the given skill (or a random one if skill is
+// negative). It returns false if the limit was reached.
+func (s *Server) AddBot(skill int32, limit int) bool {
+	if len(s.Clients.Bots()) >= limit {
+		return false
+	}
+
+	if skill < 0 {
+		skill = botMinSkill + s.rng.Int31n(botMaxSkill-botMinSkill+1)
+	} else if skill < 1 {
+		skill = 1
+	} else if skill > 101 {
+		skill = 101
+	}
+
+	name := s.botName()
+	bot := s.Clients.AddBot(newBotAI(), skill)
+	bot.server = s
+	bot.Name = name
+	bot.Model = s.rng.Int31n(128)
+	bot.Positions, bot.Packets = s.relay.AddBot(bot.CN)
+	bot.Joined = true
+	bot.State = playerstate.Dead
+
+	if teamedMode, ok := s.GameMode.(game.TeamMode); ok {
+		teamedMode.Join(&bot.Player)
+	}
+	s.Broadcast(botInitPacket(bot))
+
+	s.Spawn(bot)
+	bot.Send(P.SpawnState{Client: int32(bot.CN), EntityState: bot.ToWire()})
+
+	log.Println("added", bot)
+	return true
+}
+
+// RemoveBot removes the bot that was added last.
+func (s *Server) RemoveBot() bool {
+	bots := s.Clients.Bots()
+	if len(bots) == 0 {
+		return false
+	}
+
+	s.removeBot(bots[len(bots)-1])
+	return true
+}
+
+func (s *Server) removeBot(bot *Client) {
+	log.Println("removing", bot)
+	s.Disconnect(bot, disconnectreason.None)
+}
+
+func (s *Server) removeBots() {
+	for _, bot := range s.Clients.Bots() {
+		s.removeBot(bot)
+	}
+}
+
+func (s *Server) SetBotLimit(c *Client, limit int32) {
+	if c != nil && c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	if limit < 0 {
+		limit = 0
+	} else if limit > maxBots {
+		limit = maxBots
+	}
+	s.BotLimit = int(limit)
+
+	for len(s.Clients.Bots()) > s.BotLimit {
+		s.RemoveBot()
+	}
+	s.BalanceBots()
+
+	s.Message(fmt.Sprintf("bot limit is now %d", s.BotLimit))
+}
+
+func (s *Server) SetBotBalance(c *Client, balance bool) {
+	if c != nil && c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	s.BotBalance = balance
+	s.BalanceBots()
+
+	if balance {
+		s.Message("bot team balancing is now enabled")
+	} else {
+		s.Message("bot team balancing is now disabled")
+	}
+}
+
+// BalanceBots fills up the teams with bots (within the bot limit) so that all
+// teams are as big as the team with the most human players. It does nothing
+// unless bot balancing is enabled and a team mode is being played.
+func (s *Server) BalanceBots() {
+	teamedMode, ok := s.GameMode.(game.TeamMode)
+	if !s.BotBalance || !ok {
+		return
+	}
+
+	humans := map[*game.Team]int{}
+	numHumans := 0
+	s.Clients.ForEach(func(c *Client) {
+		if c.IsBot() || !c.Joined || c.State == playerstate.Spectator {
+			return
+		}
+		humans[c.Team]++
+		numHumans++
+	})
+
+	teams := []*game.Team{}
+	largest := 0
+	teamedMode.ForEachTeam(func(t *game.Team) {
+		teams = append(teams, t)
+		if humans[t] > largest {
+			largest = humans[t]
+		}
+	})
+
+	wanted := len(teams)*largest - numHumans
+	if wanted > s.BotLimit {
+		wanted = s.BotLimit
+	}
+
+	for len(s.Clients.Bots()) > wanted {
+		s.RemoveBot()
+	}
+	for len(s.Clients.Bots()) < wanted {
+		if !s.AddBot(-1, s.BotLimit) {
+			break
+		}
+	}
+
+	// removing bots may have left the teams uneven, so move bots from the
+	// biggest to the smallest team until they are balanced
+	if len(teams) < 2 {
+		return
+	}
+	for {
+		sort.Sort(game.BySizeAndScore(teams))
+		smallest, biggest := teams[0], teams[len(teams)-1]
+		if len(biggest.Players)-len(smallest.Players) < 2 {
+			return
+		}
+
+		var moved bool
+		for _, bot := range s.Clients.Bots() {
+			if bot.Team == biggest {
+				teamedMode.ChangeTeam(&bot.Player, smallest.Name, true)
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+
+	"github.com/rs/zerolog/log"
+)
+
+// What we do with players we catch cheating.
+const (
+	CheatActionWarn     = "warn"
+	CheatActionSpectate = "spectate"
+	CheatActionKick     = "kick"
+)
+
+const (
+	// the number of violations we tolerate before acting
+	maxViolations = 3
+	// violations older than this are forgotten
+	violationExpiry = time.Minute
+)
+
+// A Cheat is published whenever the server acts on a player it caught
+// cheating.
+type Cheat struct {
+	SessionID uint32
+	Name      string
+	Reason    string
+	Action    string
+}
+
+// Counts how often a player recently broke one kind of rule.
+type violations struct {
+	count int
+	last  time.Time
+}
+
+// Records a violation and returns whether there were enough of them to act.
+func (v *violations) add() bool {
+	if time.Since(v.last) > violationExpiry {
+		v.count = 0
+	}
+	v.count++
+	v.last = time.Now()
+
+	if v.count < maxViolations {
+		return false
+	}
+	v.count = 0
+	return true
+}
+
+// Records that c did something impossible and punishes them if they keep
+// doing it. Returns whether c is still playing.
+func (s *Server) suspect(c *Client, v *violations, reason string) bool {
+	log.Warn().
+		Str("client", c.String()).
+		Int("violations", v.count+1).
+		Msgf("suspected of %s", reason)
+
+	if !v.add() {
+		return true
+	}
+
+	return s.punishCheater(c, reason)
+}
+
+// Acts on a cheater according to the configured CheatAction and returns
+// whether they are still playing.
+func (s *Server) punishCheater(c *Client, reason string) bool {
+	action := s.Config.CheatAction
+	if action == "" {
+		action = CheatActionWarn
+	}
+
+	name := s.Clients.UniqueName(c)
+	switch action {
+	case CheatActionSpectate:
+		s.SetSpectator(c, true)
+		s.Message(cubecode.Fail(fmt.Sprintf("%s was moved to spectators for %s", name, reason)))
+	case CheatActionKick:
+		msg := fmt.Sprintf("%s was kicked for %s", name, reason)
+		s.Message(cubecode.Fail(msg))
+		s.kick(c, msg)
+	default:
+		action = CheatActionWarn
+		s.Message(cubecode.Fail(fmt.Sprintf("%s is suspected of %s", name, reason)))
+	}
+
+	s.Cheats.Publish(Cheat{
+		SessionID: c.SessionID,
+		Name:      c.Name,
+		Reason:    reason,
+		Action:    action,
+	})
+
+	return action == CheatActionWarn
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/mastermode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// In a clan war, teams play a match under tournament rules: the server is
+// locked, the teams can't change, and the clock only starts once every player
+// typed #ready. Each team may pause the game a limited number of times. When
+// the teams are tied as time runs out, the match goes into overtime, either
+// for ClanWarOvertime seconds at a time or, if that is 0, until the next team
+// scores. At the end, the server prints the final scoreboard.
+
+const (
+	// how often we check the scores in sudden death
+	suddenDeathCheckInterval = 250 * time.Millisecond
+	// how long sudden death lasts before the match is a draw
+	maxSuddenDeath = 10 * time.Minute
+)
+
+type clanWar struct {
+	// players that are ready to start
+	ready map[*Client]struct{}
+	// set once everyone was ready
+	started bool
+	// pauses used by each team
+	pauses map[*game.Team]int
+	// how many times the match went into overtime
+	overtime    int
+	suddenDeath bool
+}
+
+// SetClanWar starts or ends clan war mode. Starting it restarts the current
+// map.
+func (s *Server) SetClanWar(c *Client, on bool) {
+	if c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+	if on == s.ClanWar {
+		return
+	}
+
+	if !on {
+		s.endClanWar()
+		s.Message(fmt.Sprintf("%s ended the clan war", s.Clients.UniqueName(c)))
+		return
+	}
+
+	if _, ok := s.GameMode.(game.TeamMode); !ok {
+		c.Message(cubecode.Fail("clan wars need a team mode"))
+		return
+	}
+
+	s.ClanWar = true
+	s.TeamsLocked = true
+	s.SetMasterMode(c, mastermode.Locked)
+	s.Message(fmt.Sprintf("%s started a clan war", s.Clients.UniqueName(c)))
+	s.StartGame(s.StartMode(s.GameMode.ID()), s.Map)
+}
+
+// Leaves clan war mode and lets a match that is waiting for players to get
+// ready start.
+func (s *Server) endClanWar() {
+	s.ClanWar = false
+	s.TeamsLocked = false
+
+	war := s.war
+	s.war = nil
+	if war == nil || war.started {
+		return
+	}
+	// a master might have resumed the game already
+	if clock, ok := s.Clock.(game.Competitive); ok && clock.Release() && s.Clock.Paused() {
+		s.Clock.Resume(nil)
+	}
+}
+
+// Sets up the clan war for a new match, if one is being played.
+func (s *Server) startClanWar() {
+	s.war = nil
+	if !s.ClanWar {
+		return
+	}
+
+	clock, ok := s.Clock.(game.Competitive)
+	if _, teams := s.GameMode.(game.TeamMode); !ok || !teams {
+		s.Message("clan wars need a team mode, this is a normal match")
+		return
+	}
+
+	s.war = &clanWar{
+		ready:  map[*Client]struct{}{},
+		pauses: map[*game.Team]int{},
+	}
+	clock.Hold()
+	s.Message(fmt.Sprintf("clan war: type %s when your team is ready", cubecode.Green("#ready")))
+}
+
+// Ready marks c as ready to start the clan war, or not ready anymore.
+func (s *Server) Ready(c *Client, ready bool) {
+	switch {
+	case s.war == nil:
+		c.Message(cubecode.Fail("there is no clan war going on"))
+		return
+	case s.war.started:
+		c.Message(cubecode.Fail("the match already started"))
+		return
+	case c.State == playerstate.Spectator:
+		c.Message(cubecode.Fail("spectators don't play"))
+		return
+	}
+
+	if !ready {
+		delete(s.war.ready, c)
+		s.Message(fmt.Sprintf("%s is not ready", s.Clients.UniqueName(c)))
+		return
+	}
+
+	s.war.ready[c] = struct{}{}
+	s.Message(fmt.Sprintf("%s is ready", s.Clients.UniqueName(c)))
+	s.checkReady()
+}
+
+// Starts the clan war once every player of at least two teams is ready.
+func (s *Server) checkReady() {
+	if s.war == nil || s.war.started {
+		return
+	}
+
+	members := s.teamMembers()
+	if len(members) < 2 {
+		return
+	}
+	waiting := []string{}
+	for _, team := range members {
+		for _, c := range team {
+			if _, ready := s.war.ready[c]; !ready && !c.IsBot() {
+				waiting = append(waiting, s.Clients.UniqueName(c))
+			}
+		}
+	}
+	if len(waiting) > 0 {
+		sort.Strings(waiting)
+		s.Message(fmt.Sprintf("waiting for %s", strings.Join(waiting, ", ")))
+		return
+	}
+
+	s.war.started = true
+	s.Message("everyone is ready, the clan war starts")
+	// a master might have resumed the game already
+	if clock, ok := s.Clock.(game.Competitive); ok && clock.Release() && s.Clock.Paused() {
+		s.Clock.Resume(nil)
+	}
+}
+
+// Forgets that c was ready, because they left or went to spectators.
+func (s *Server) leaveClanWar(c *Client) {
+	if s.war == nil {
+		return
+	}
+	delete(s.war.ready, c)
+	// they might have been the last player we waited for
+	s.checkReady()
+}
+
+// Whether c may pause or resume the game. During a clan war, players that
+// aren't masters can't start the game before everyone is ready, and use up
+// one of their team's pauses when they pause it.
+func (s *Server) canPause(c *Client, paused bool) bool {
+	if s.war == nil || c.Role != role.None {
+		return true
+	}
+	if !s.war.started {
+		c.Message(cubecode.Fail("the match starts once everyone is ready"))
+		return false
+	}
+	// resuming and aborting a pending resume are free
+	if !paused || s.Clock.Paused() {
+		return true
+	}
+	if c.State == playerstate.Spectator {
+		c.Message(cubecode.Fail("spectators can't pause the game"))
+		return false
+	}
+
+	left := s.ClanWarPauses - s.war.pauses[c.Team]
+	if left <= 0 {
+		c.Message(cubecode.Fail("your team has no pauses left"))
+		return false
+	}
+	s.war.pauses[c.Team]++
+	s.Message(fmt.Sprintf("%s paused the game, team %s has %d pauses left", s.Clients.UniqueName(c), c.Team.Name, left-1))
+	return true
+}
+
+// Modes with objectives count points, others frags.
+func (s *Server) teamScore(t *game.Team) int32 {
+	switch s.GameMode.(type) {
+	case game.FlagMode, game.CaptureMode, game.CollectMode:
+		return t.Score
+	default:
+		return t.Frags
+	}
+}
+
+// Returns the teams with the highest score.
+func (s *Server) leadingTeams() (leaders []*game.Team) {
+	teamMode, ok := s.GameMode.(game.TeamMode)
+	if !ok {
+		return nil
+	}
+	teamMode.ForEachTeam(func(t *game.Team) {
+		switch {
+		case len(leaders) == 0 || s.teamScore(t) > s.teamScore(leaders[0]):
+			leaders = []*game.Team{t}
+		case s.teamScore(t) == s.teamScore(leaders[0]):
+			leaders = append(leaders, t)
+		}
+	})
+	return
+}
+
+// Sends a clan war that is tied when time runs out into overtime. It returns
+// true if it did, in which case the match goes on.
+func (s *Server) overtime() bool {
+	if s.war == nil {
+		return false
+	}
+	leaders := s.leadingTeams()
+	if len(leaders) < 2 {
+		return false
+	}
+	clock, ok := s.Clock.(game.Competitive)
+	if !ok || s.war.suddenDeath {
+		return false
+	}
+
+	s.war.overtime++
+	score := s.teamScore(leaders[0])
+	if s.ClanWarOvertime > 0 {
+		clock.Overtime(time.Duration(s.ClanWarOvertime) * time.Second)
+		s.Message(fmt.Sprintf("the teams are tied at %d, overtime %d: %d more seconds", score, s.war.overtime, s.ClanWarOvertime))
+		return true
+	}
+
+	s.war.suddenDeath = true
+	clock.Overtime(maxSuddenDeath)
+	s.Message(fmt.Sprintf("the teams are tied at %d, sudden death: the next team to score wins", score))
+	return true
+}
+
+// Ends the match in sudden death as soon as one team leads.
+func (s *Server) checkSuddenDeath() {
+	if s.war == nil || !s.war.suddenDeath || s.Clock.Ended() {
+		return
+	}
+	if leaders := s.leadingTeams(); len(leaders) == 1 {
+		s.Intermission()
+	}
+}
+
+// Prints the final scores of the clan war, best team first.
+func (s *Server) clanWarScoreboard() {
+	if s.war == nil {
+		return
+	}
+
+	members := s.teamMembers()
+	teams := []*game.Team{}
+	for team := range members {
+		teams = append(teams, team)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return s.teamScore(teams[i]) > s.teamScore(teams[j])
+	})
+
+	s.Message(cubecode.Yellow("final scores"))
+	for _, team := range teams {
+		players := members[team]
+		sort.Slice(players, func(i, j int) bool {
+			return players[i].Frags > players[j].Frags
+		})
+		names := []string{}
+		for _, c := range players {
+			names = append(names, fmt.Sprintf("%s (%d)", s.Clients.UniqueName(c), c.Frags))
+		}
+		s.Message(fmt.Sprintf("%s %d: %s", cubecode.Green(team.Name), s.teamScore(team), strings.Join(names, ", ")))
+	}
+
+	if leaders := s.leadingTeams(); len(leaders) == 1 {
+		s.Message(fmt.Sprintf("team %s wins the clan war", leaders[0].Name))
+	} else {
+		s.Message("the clan war is a draw")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/auth"
 	"github.com/cfoust/sour/pkg/server/game"
 	"github.com/cfoust/sour/pkg/server/protocol/disconnectreason"
 	"github.com/cfoust/sour/pkg/server/protocol/role"
@@ -17,6 +18,12 @@ var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 type Authentication struct {
 	reqID uint32
 	name  string
+	role  role.ID
+
+	// the challenge the client still has to answer, if any
+	challenge *auth.Challenge
+	// who to kick once the client answered, for N_AUTHKICK
+	victim *Client
 }
 
 // Describes a client.
@@ -32,6 +39,32 @@ type Client struct {
 	Packets             *relay.Publisher
 	Authentications     map[string]*Authentication
 
+	// the account the player was last known to be logged in with, 0 if
+	// none
+	Account uint
+
+	// set for bots, which are run by the server
+	ai    *botAI
+	Skill int32
+
+	// the CRC of the current map the client reported, 0 if none
+	MapCRC      int32
+	ModifiedMap bool
+
+	movement movement
+	// where the player was recently, to validate hits against
+	history positionHistory
+	// when we last answered the client's N_PING, and the round trip time
+	// we measured from that
+	pongSent time.Time
+	latency  time.Duration
+	// hits on players that were not in the line of fire
+	impossibleHits violations
+
+	// when the player last moved, shot or chatted
+	lastActive time.Time
+	afkWarned  bool
+
 	connected chan bool
 	outgoing  Outgoing
 
@@ -59,14 +92,30 @@ func (c *Client) String() string {
 	return fmt.Sprintf("%s (%d:%d)", c.Name, c.CN, c.SessionID)
 }
 
+func (c *Client) IsBot() bool {
+	return c.ai != nil
+}
+
 func (c *Client) Message(text string) {
+	if c.IsBot() {
+		return
+	}
 	c.Send(protocol.ServerMessage{Text: text})
 }
 
+// Send sends messages to the client. Messages for bots go to their AI.
 func (c *Client) Send(messages ...protocol.Message) {
+	c.sendOnChannel(1, messages...)
+}
+
+func (c *Client) sendOnChannel(channel uint8, messages ...protocol.Message) {
+	if c.IsBot() {
+		c.ai.receive(messages...)
+		return
+	}
 	c.outgoing <- ServerPacket{
 		Session:  c.SessionID,
-		Channel:  1,
+		Channel:  channel,
 		Messages: messages,
 	}
 }
@@ -23,9 +23,8 @@ type ClientManager struct {
 	broadcasts *utils.Topic[[]P.Message]
 }
 
-func (cm *ClientManager) Add(sessionId uint32, outgoing Outgoing) *Client {
-	cm.mutex.Lock()
-
+// returns the lowest CN not in use. cm.mutex must be held by the caller.
+func (cm *ClientManager) freeCN() uint32 {
 	taken := make(map[uint32]struct{})
 	for _, client := range cm.clients {
 		taken[client.CN] = struct{}{}
@@ -39,7 +38,23 @@ func (cm *ClientManager) Add(sessionId uint32, outgoing Outgoing) *Client {
 		cn++
 	}
 
-	c := NewClient(cn, sessionId, outgoing)
+	return cn
+}
+
+func (cm *ClientManager) Add(sessionId uint32, outgoing Outgoing) *Client {
+	cm.mutex.Lock()
+	c := NewClient(cm.freeCN(), sessionId, outgoing)
+	cm.clients = append(cm.clients, c)
+	cm.mutex.Unlock()
+	return c
+}
+
+// AddBot adds a bot run by ai. Bots get a CN like any other client.
+func (cm *ClientManager) AddBot(ai *botAI, skill int32) *Client {
+	cm.mutex.Lock()
+	c := NewClient(cm.freeCN(), 0, nil)
+	c.ai = ai
+	c.Skill = skill
 	cm.clients = append(cm.clients, c)
 	cm.mutex.Unlock()
 	return c
@@ -49,11 +64,13 @@ func (cm *ClientManager) GetClientByCN(cn uint32) *Client {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
-	if int(cn) < 0 || int(cn) >= len(cm.clients) {
-		return nil
+	for _, client := range cm.clients {
+		if client.CN == cn {
+			return client
+		}
 	}
 
-	return cm.clients[cn]
+	return nil
 }
 
 func (cm *ClientManager) GetClientByID(sessionId uint32) *Client {
@@ -61,7 +78,7 @@ func (cm *ClientManager) GetClientByID(sessionId uint32) *Client {
 	defer cm.mutex.RUnlock()
 
 	for _, client := range cm.clients {
-		if client.SessionID == sessionId {
+		if client.SessionID == sessionId && !client.IsBot() {
 			return client
 		}
 	}
@@ -69,6 +86,16 @@ func (cm *ClientManager) GetClientByID(sessionId uint32) *Client {
 	return nil
 }
 
+// Returns all bots.
+func (cm *ClientManager) Bots() (bots []*Client) {
+	cm.ForEach(func(c *Client) {
+		if c.IsBot() {
+			bots = append(bots, c)
+		}
+	})
+	return
+}
+
 func (cm *ClientManager) FindClientByName(name string) *Client {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
@@ -106,7 +133,8 @@ func (cm *ClientManager) broadcast(exclude func(*Client) bool, messages ...P.Mes
 	cm.broadcasts.Publish(messages)
 
 	for _, c := range cm.clients {
-		if exclude != nil && exclude(c) {
+		// the server runs the bots, so they don't need to know
+		if c.IsBot() || exclude != nil && exclude(c) {
 			continue
 		}
 
@@ -126,6 +154,13 @@ func (cm *ClientManager) Relay(from *Client, messages ...P.Message) {
 
 // Sends 'welcome' information to a newly joined client like map, mode, time left, other players, etc.
 func (s *Server) SendWelcome(c *Client) {
+	c.Send(s.welcomePacket(c)...)
+}
+
+// Builds the welcome information for c. If c is nil, the packet describes the
+// game from the point of view of a spectator that is not part of it (used for
+// demos).
+func (s *Server) welcomePacket(c *Client) []P.Message {
 	messages := []P.Message{
 		P.Welcome{},
 		P.MapChange{
@@ -137,6 +172,10 @@ func (s *Server) SendWelcome(c *Client) {
 		P.TimeUp{int32(s.Clock.TimeLeft() / time.Second)},
 	}
 
+	if s.Speed != defaultGameSpeed {
+		messages = append(messages, P.GameSpeed{Speed: s.Speed, Client: -1})
+	}
+
 	if pickupMode, ok := s.GameMode.(game.PickupMode); ok && !s.GameMode.NeedsMapInfo() {
 		messages = append(messages, pickupMode.PickupsInitPacket())
 	}
@@ -163,25 +202,27 @@ func (s *Server) SendWelcome(c *Client) {
 		messages = append(messages, teamInfo)
 	}
 
-	// tell the client what team he was put in by the server
-	messages = append(messages, P.SetTeam{
-		Client: int32(c.CN),
-		Team:   c.Team.Name,
-		Reason: -1,
-	})
-
-	// tell the client how to spawn (what health, what armour, what weapons, what ammo, etc.)
-	if c.State == playerstate.Spectator {
-		messages = append(messages, P.Spectator{
-			Client:     int32(c.CN),
-			Spectating: true,
-		})
-	} else {
-		// TODO: handle spawn delay (e.g. in ctf modes)
-		messages = append(messages, P.SpawnState{
-			Client:      int32(c.CN),
-			EntityState: c.ToWire(),
+	if c != nil {
+		// tell the client what team he was put in by the server
+		messages = append(messages, P.SetTeam{
+			Client: int32(c.CN),
+			Team:   c.Team.Name,
+			Reason: -1,
 		})
+
+		// tell the client how to spawn (what health, what armour, what weapons, what ammo, etc.)
+		if c.State == playerstate.Spectator {
+			messages = append(messages, P.Spectator{
+				Client:     int32(c.CN),
+				Spectating: true,
+			})
+		} else {
+			// TODO: handle spawn delay (e.g. in ctf modes)
+			messages = append(messages, P.SpawnState{
+				Client:      int32(c.CN),
+				EntityState: c.ToWire(),
+			})
+		}
 	}
 
 	// send other players' state (frags, flags, etc.)
@@ -206,6 +247,10 @@ func (s *Server) SendWelcome(c *Client) {
 
 	// send other client's state (name, team, playermodel)
 	for _, client := range s.Clients.clients {
+		if client.IsBot() {
+			messages = append(messages, botInitPacket(client))
+			continue
+		}
 		if client != c {
 			messages = append(messages, P.InitClient{
 				int32(client.CN), client.Name, client.Team.Name, int32(client.Model),
@@ -213,7 +258,7 @@ func (s *Server) SendWelcome(c *Client) {
 		}
 	}
 
-	c.Send(messages...)
+	return messages
 }
 
 // Tells other clients that the client disconnected, giving a disconnect reason in case it's not a normal leave.
@@ -293,12 +338,17 @@ func (s *Server) PrivilegedUsersPacket() (P.Message, bool) {
 	return message, len(message.Clients) == 0
 }
 
-// Returns the number of connected clients.
+// Returns the number of connected clients, not counting bots.
 func (cm *ClientManager) GetNumClients() (n int) {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
-	return len(cm.clients)
+	for _, c := range cm.clients {
+		if !c.IsBot() {
+			n++
+		}
+	}
+	return
 }
 
 func (cm *ClientManager) ForEach(do func(c *Client)) {
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"github.com/cfoust/sour/pkg/game/commands"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) registerCommands() {
+	err := s.Commands.Register(
+		commands.Command{
+			Name:        "lockteams",
+			ArgFormat:   "[0|1]",
+			Description: "stops the server from balancing teams and players from switching teams",
+			Callback: func(c *Client, locked *bool) {
+				if locked == nil {
+					s.LockTeams(c, !s.TeamsLocked)
+					return
+				}
+				s.LockTeams(c, *locked)
+			},
+		},
+		commands.Command{
+			Name:        "clanwar",
+			ArgFormat:   "[0|1]",
+			Description: "starts a clan war on the current map: teams are locked, the game starts once everyone is ready, and tied matches go into overtime",
+			Callback: func(c *Client, on *bool) {
+				if on == nil {
+					s.SetClanWar(c, !s.ClanWar)
+					return
+				}
+				s.SetClanWar(c, *on)
+			},
+		},
+		commands.Command{
+			Name:        "ready",
+			ArgFormat:   "[0|1]",
+			Description: "tells everyone you're ready to start the clan war",
+			Callback: func(c *Client, ready *bool) {
+				if ready == nil {
+					s.Ready(c, true)
+					return
+				}
+				s.Ready(c, *ready)
+			},
+		},
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("could not register server commands")
+	}
+}
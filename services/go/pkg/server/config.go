@@ -7,4 +7,98 @@ type Config struct {
 	DefaultMode      string
 	DefaultMap       string
 	Maps             []string
+	// seconds clients are banned for after being kicked, 0 to disable
+	KickBanDuration int
+	// what to do with players that move in impossible ways: "warn",
+	// "spectate" or "kick"
+	CheatAction string
+	// keys players can claim privileges with using the game's /auth and
+	// /sauth commands
+	AuthKeys []AuthKey
+	// percentage of players that have to vote for a map for it to be played
+	// right away
+	VoteMajority int
+	// seconds a map vote stays open before the map with the most votes wins
+	VoteDuration int
+	// what to play after each match, instead of one of Maps at random
+	Rotation []RotationEntry
+	// whether to go through Rotation in a random order
+	ShuffleRotation bool
+	// how many of the maps played last not to play again for now
+	RotationHistory int
+	// seconds after which players that don't move, shoot or chat are moved
+	// to spectators, 0 to disable
+	AfkTimeout int
+	// seconds after which idle spectators are disconnected when the server
+	// is almost full, 0 to disable
+	AfkSpectatorTimeout int
+	// play instagib, efficiency and tactics modes as elimination: one life
+	// per round, the last player or team standing wins the round
+	Elimination bool
+	// play gun game instead of free for all modes; the game only hands out
+	// weapons by spawning players, so players getting the next weapon are
+	// moved to a spawn point and lose quad damage and projectiles in flight
+	GunGame bool
+	// the weapons players go through in gun game, by name ("chainsaw",
+	// "shotgun", "chaingun", "rocketlauncher", "rifle", "grenadelauncher" or
+	// "pistol"), the default ladder if empty
+	GunGameLadder []string
+	// move players between teams during matches to keep the teams even
+	BalanceTeams bool
+	// how many times each team may pause a clan war
+	ClanWarPauses int
+	// seconds of overtime when a clan war is tied, sudden death if 0
+	ClanWarOvertime int
+	// changes to the stock weapons and what players spawn with
+	Weapons []WeaponConfig
+	// health and armour players spawn with, the mode's if 0
+	SpawnHealth int
+	SpawnArmour int
+	// the weapon players hold when they spawn, by name, the mode's if empty
+	SpawnWeapon string
+	// how long it takes for items to respawn, instead of the stock delays
+	PickupRespawn []PickupRespawn
+}
+
+type WeaponConfig struct {
+	// the weapon's name, as in GunGameLadder
+	Weapon string
+	// damage per ray, the stock damage if 0
+	Damage int
+	// rays that can hit per shot, the stock number if 0; clients always
+	// fire the stock number, so it can't be higher
+	Rays int
+	// how far the weapon hits, the stock range if 0; clients don't shoot
+	// farther, so it can't be higher
+	Range float64
+	// ammo players spawn with, what the mode gives them if 0, none if
+	// negative
+	Ammo int
+}
+
+type PickupRespawn struct {
+	// "shells", "bullets", "rockets", "riflerounds", "grenades",
+	// "cartridges", "health", "healthboost", "greenarmour", "yellowarmour"
+	// or "quaddamage"
+	Item    string
+	Seconds int
+}
+
+type RotationEntry struct {
+	// the mode to play, the current one if empty
+	Mode string
+	Map  string
+	// only play this when there are at least MinPlayers and at most
+	// MaxPlayers players, 0 for no limit
+	MinPlayers int
+	MaxPlayers int
+}
+
+type AuthKey struct {
+	Name   string
+	Domain string
+	// the public key, as printed by the game's /genauthkey
+	Key string
+	// "master", "auth" or "admin", "auth" if empty
+	Role string
 }
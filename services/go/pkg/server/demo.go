@@ -0,0 +1,295 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"log"
+	"time"
+
+	C "github.com/cfoust/sour/pkg/game/constants"
+	"github.com/cfoust/sour/pkg/game/io"
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// values taken from server.cpp in the vanilla game
+const (
+	maxDemos    = 5
+	maxDemoSize = 16 * 1024 * 1024
+)
+
+// A Demo is a finished recording of a match.
+type Demo struct {
+	Info    string
+	Map     string
+	Mode    gamemode.ID
+	Started time.Time
+	// the gzipped demo file, as the game reads it
+	Data []byte
+}
+
+// demoRecorder records everything that happens in a match from the point of
+// view of a spectator: all broadcasts and all relayed positions and packets.
+type demoRecorder struct {
+	mutex   deadlock.Mutex
+	started time.Time
+	mapName string
+	mode    gamemode.ID
+	buffer  io.Buffer
+	full    bool
+	done    chan struct{}
+}
+
+func newDemoRecorder(mapName string, mode gamemode.ID) *demoRecorder {
+	d := &demoRecorder{
+		started: time.Now(),
+		mapName: mapName,
+		mode:    mode,
+		done:    make(chan struct{}),
+	}
+
+	d.buffer.Put(
+		[]byte(C.DEMO_MAGIC),
+		int32(C.DEMO_VERSION),
+		int32(P.PROTOCOL_VERSION),
+	)
+
+	return d
+}
+
+// splits messages relayed on channel 1 into packets, each starting with
+// N_CLIENT, since the length field of N_CLIENT only covers a single client.
+func splitClientPackets(messages []P.Message) [][]P.Message {
+	packets := [][]P.Message{}
+	for _, message := range messages {
+		if message.Type() == P.N_CLIENT || len(packets) == 0 {
+			packets = append(packets, []P.Message{})
+		}
+		packets[len(packets)-1] = append(packets[len(packets)-1], message)
+	}
+	return packets
+}
+
+func (d *demoRecorder) Record(channel uint8, messages []P.Message) {
+	if len(messages) == 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.full {
+		return
+	}
+
+	millis := int32(time.Since(d.started).Round(time.Millisecond).Milliseconds())
+
+	for _, packet := range splitClientPackets(messages) {
+		data, err := P.Encode(packet...)
+		if err != nil {
+			log.Println("failed to encode packet for demo:", err)
+			continue
+		}
+
+		d.buffer.Put(
+			millis,
+			int32(channel),
+			int32(len(data)),
+			data,
+		)
+	}
+
+	if len(d.buffer) > maxDemoSize {
+		log.Println("demo is too big, no longer recording")
+		d.full = true
+	}
+}
+
+// Finish stops the recording and returns the compressed demo.
+func (d *demoRecorder) Finish() (*Demo, error) {
+	close(d.done)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write(d.buffer)
+	if err != nil {
+		return nil, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	size := float64(compressed.Len())
+	unit := "kB"
+	size /= 1024
+	if size >= 1024 {
+		size /= 1024
+		unit = "MB"
+	}
+
+	return &Demo{
+		Info:    fmt.Sprintf("%s: %s, %s, %.2f%s", d.started.Format("Mon Jan 2 15:04:05 2006"), d.mode, d.mapName, size, unit),
+		Map:     d.mapName,
+		Mode:    d.mode,
+		Started: d.started,
+		Data:    compressed.Bytes(),
+	}, nil
+}
+
+// Starts recording the match that just began, if demos are enabled.
+func (s *Server) startDemo() {
+	if !s.RecordDemos || s.GameMode.ID() == gamemode.CoopEdit {
+		return
+	}
+
+	d := newDemoRecorder(s.Map, s.GameMode.ID())
+	d.Record(1, append(s.welcomePacket(nil), s.modeInitPacket()...))
+
+	broadcasts := s.Broadcasts.Subscribe()
+	go func() {
+		for {
+			select {
+			case messages := <-broadcasts.Recv():
+				d.Record(1, messages)
+			case <-d.done:
+				// keep receiving until we're unsubscribed, Publish()
+				// might be waiting for us
+				unsubscribed := make(chan struct{})
+				go func() {
+					broadcasts.Done()
+					close(unsubscribed)
+				}()
+				for {
+					select {
+					case <-broadcasts.Recv():
+					case <-unsubscribed:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	s.demoMutex.Lock()
+	previous := s.demo
+	s.demo = d
+	s.relay.Observe(d.Record)
+	s.demoMutex.Unlock()
+
+	// we might have started a match before the last one was over
+	if previous != nil {
+		s.finishDemo(previous)
+	}
+}
+
+// Stops recording and stores the demo.
+func (s *Server) stopDemo() {
+	s.demoMutex.Lock()
+	d := s.demo
+	s.demo = nil
+	if d != nil {
+		s.relay.Observe(nil)
+	}
+	s.demoMutex.Unlock()
+
+	if d != nil {
+		s.finishDemo(d)
+	}
+}
+
+func (s *Server) finishDemo(d *demoRecorder) {
+	demo, err := d.Finish()
+	if err != nil {
+		log.Println("failed to finish demo:", err)
+		return
+	}
+
+	s.demosMutex.Lock()
+	s.demos = append(s.demos, demo)
+	if len(s.demos) > maxDemos {
+		s.demos = s.demos[len(s.demos)-maxDemos:]
+	}
+	s.demosMutex.Unlock()
+
+	s.FinishedDemos.Publish(demo)
+	s.Message(fmt.Sprintf("demo \"%s\" recorded", demo.Info))
+}
+
+func (s *Server) SetRecordDemos(c *Client, enabled bool) {
+	if c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	s.RecordDemos = enabled
+	if enabled {
+		s.Message("demo recording is enabled for next match")
+	} else {
+		s.Message("demo recording is disabled for next match")
+	}
+}
+
+func (s *Server) SendDemoList(c *Client) {
+	s.demosMutex.Lock()
+	defer s.demosMutex.Unlock()
+
+	message := P.SendDemoList{}
+	for _, demo := range s.demos {
+		message.Demos = append(message.Demos, struct{ Info string }{demo.Info})
+	}
+	c.Send(message)
+}
+
+// SendDemo sends the demo with the given (1-based) index to c. 0 refers to
+// the most recent demo.
+func (s *Server) SendDemo(c *Client, index int32, tag int32) {
+	s.demosMutex.Lock()
+	defer s.demosMutex.Unlock()
+
+	if index == 0 {
+		index = int32(len(s.demos))
+	}
+	if index < 1 || int(index) > len(s.demos) {
+		c.Message(cubecode.Fail(fmt.Sprintf("no demo %d available", index)))
+		return
+	}
+
+	c.sendOnChannel(2, P.SendDemo{
+		Tag:  tag,
+		Data: s.demos[index-1].Data,
+	})
+}
+
+// ClearDemos deletes the demo with the given (1-based) index, or all demos if
+// index is 0.
+func (s *Server) ClearDemos(c *Client, index int32) {
+	if c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	s.demosMutex.Lock()
+	defer s.demosMutex.Unlock()
+
+	if index == 0 {
+		s.demos = nil
+		s.Message("cleared all demos")
+		return
+	}
+	if index < 1 || int(index) > len(s.demos) {
+		c.Message(cubecode.Fail(fmt.Sprintf("no demo %d available", index)))
+		return
+	}
+
+	s.demos = append(s.demos[:index-1], s.demos[index:]...)
+	s.Message(fmt.Sprintf("cleared demo %d", index))
+}
@@ -2,12 +2,49 @@ package server
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/cfoust/sour/pkg/server/game"
 	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
 )
 
 func (s *Server) StartMode(id gamemode.ID) game.Mode {
+	if s.GunGame && game.CanPlayGunGame(id) {
+		return game.NewGunGame(s, id, s.gunGameLadder())
+	}
+
+	mode := s.stockMode(id)
+	if !s.Elimination {
+		return mode
+	}
+
+	if !game.CanEliminate(id) {
+		log.Println("can't play elimination with the rules of", id)
+		return mode
+	}
+
+	if teamMode, ok := mode.(game.TeamMode); ok {
+		return game.NewTeamElimination(s, mode, teamMode)
+	}
+	return game.NewElimination(s, mode)
+}
+
+// Returns the weapons of the configured gun game ladder.
+func (s *Server) gunGameLadder() []weapon.ID {
+	ladder := []weapon.ID{}
+	for _, name := range s.GunGameLadder {
+		id, ok := weapon.Parse(name)
+		if !ok {
+			log.Printf("unknown weapon %q in gun game ladder", name)
+			continue
+		}
+		ladder = append(ladder, id)
+	}
+	return ladder
+}
+
+func (s *Server) stockMode(id gamemode.ID) game.Mode {
 	switch id {
 	case gamemode.FFA:
 		return game.NewFFA(s)
@@ -27,12 +64,34 @@ func (s *Server) StartMode(id gamemode.ID) game.Mode {
 		return game.NewTactics(s)
 	case gamemode.TacticsTeam:
 		return game.NewTacticsTeam(s, s.KeepTeams)
+	case gamemode.Capture:
+		return game.NewCapture(s, s.KeepTeams)
+	case gamemode.RegenCapture:
+		return game.NewRegenCapture(s, s.KeepTeams)
 	case gamemode.CTF:
 		return game.NewCTF(s, s.KeepTeams)
 	case gamemode.InstaCTF:
 		return game.NewInstaCTF(s, s.KeepTeams)
 	case gamemode.EfficCTF:
 		return game.NewEfficCTF(s, s.KeepTeams)
+	case gamemode.Protect:
+		return game.NewProtect(s, s.KeepTeams)
+	case gamemode.InstaProtect:
+		return game.NewInstaProtect(s, s.KeepTeams)
+	case gamemode.EfficProtect:
+		return game.NewEfficProtect(s, s.KeepTeams)
+	case gamemode.Hold:
+		return game.NewHold(s, s.KeepTeams)
+	case gamemode.InstaHold:
+		return game.NewInstaHold(s, s.KeepTeams)
+	case gamemode.EfficHold:
+		return game.NewEfficHold(s, s.KeepTeams)
+	case gamemode.Collect:
+		return game.NewCollect(s, s.KeepTeams)
+	case gamemode.InstaCollect:
+		return game.NewInstaCollect(s, s.KeepTeams)
+	case gamemode.EfficCollect:
+		return game.NewEfficCollect(s, s.KeepTeams)
 	default:
 		panic(fmt.Sprintf("unhandled gamemode ID %d", id))
 	}
@@ -0,0 +1,470 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/cfoust/sour/pkg/server/protocol/entity"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+	"github.com/cfoust/sour/pkg/server/timer"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// values taken from capture.h in the vanilla game
+const (
+	captureRadius      = 64
+	captureHeight      = 24
+	occupyBonus        = 1
+	occupyPoints       = 1
+	occupyEnemyLimit   = 28
+	occupyNeutralLimit = 14
+	scoreSeconds       = 10
+	ammoSeconds        = 15
+	regenSeconds       = 1
+	regenHealth        = 10
+	regenArmour        = 10
+	regenAmmo          = 20 // percent of an ammo pickup
+	maxBaseAmmo        = 5
+	maxBases           = 100
+	winningScore       = 10000
+)
+
+type CaptureMode interface {
+	NeedsMapInfo() bool
+	InitBases([]P.ClientBaseState)
+	BasesInitPacket() []P.Message
+}
+
+type base struct {
+	index     int32
+	ammoType  weapon.ID
+	position  *geom.Vector
+	owner     *Team
+	enemy     *Team
+	converted int32
+	ammo      int32
+	// seconds since the current owner captured the base
+	captureTime int32
+}
+
+func (b *base) contains(v *geom.Vector) bool {
+	if v == nil {
+		return false
+	}
+	dx, dy, dz := b.position.X()-v.X(), b.position.Y()-v.Y(), b.position.Z()-v.Z()
+	return dx*dx+dy*dy <= captureRadius*captureRadius && -captureHeight <= dz && dz <= captureHeight
+}
+
+func (b *base) noEnemy() {
+	b.enemy = nil
+	b.converted = 0
+}
+
+// occupy advances (or, for negative units, rolls back) the conversion of the
+// base by the enemy team. It returns true if ownership of the base changed.
+func (b *base) occupy(units int32) bool {
+	b.converted += units
+	if units < 0 {
+		if b.converted <= 0 {
+			b.noEnemy()
+		}
+		return false
+	}
+
+	limit := int32(occupyNeutralLimit)
+	if b.owner != nil {
+		limit = occupyEnemyLimit
+	}
+	if b.converted < limit {
+		return false
+	}
+
+	if b.owner != nil {
+		// enemy neutralized the base, but has to convert it again to own it
+		b.owner = nil
+		b.converted = 0
+	} else {
+		b.owner = b.enemy
+		b.ammo = 0
+		b.captureTime = 0
+		b.noEnemy()
+	}
+	return true
+}
+
+func teamName(t *Team) string {
+	if t == nil {
+		return ""
+	}
+	return t.Name
+}
+
+func (b *base) infoPacket() P.BaseInfo {
+	info := P.BaseInfo{
+		Base:  b.index,
+		Owner: teamName(b.owner),
+		Enemy: teamName(b.enemy),
+	}
+	if b.enemy != nil {
+		info.Converted = b.converted
+	}
+	if b.owner != nil {
+		info.AmmoCount = b.ammo
+	}
+	return info
+}
+
+type handlesBases struct {
+	*teamMode
+	s          Server
+	regen      bool
+	mutex      deadlock.Mutex
+	bases      []*base
+	nextUpdate *timer.Timer
+	cleanedUp  bool
+}
+
+var (
+	_ CaptureMode    = &handlesBases{}
+	_ HasTimers      = &handlesBases{}
+	_ TeamMode       = &handlesBases{}
+	_ HandlesPackets = &handlesBases{}
+)
+
+func handlingBases(s Server, keepTeams, regen bool) *handlesBases {
+	m := &handlesBases{
+		teamMode: withTeams(s, true, keepTeams, NewTeam("good"), NewTeam("evil")),
+		s:        s,
+		regen:    regen,
+	}
+	m.scheduleUpdate()
+	return m
+}
+
+func (m *handlesBases) scheduleUpdate() {
+	m.nextUpdate = gameTimer(m.s, time.Second, func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.cleanedUp {
+			return
+		}
+		m.update()
+		m.scheduleUpdate()
+	})
+	m.nextUpdate.Start()
+}
+
+func (m *handlesBases) NeedsMapInfo() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.bases) == 0
+}
+
+func (m *handlesBases) HandlePacket(p *Player, message P.Message) bool {
+	switch message.Type() {
+	case P.N_BASES:
+		if p.State == playerstate.Spectator {
+			break
+		}
+		m.InitBases(message.(P.ClientBases).Bases)
+
+	case P.N_REPAMMO:
+		m.replenishAmmo(p)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// InitBases sets up the bases of the current map, either from the map's
+// entities or from the list the first client to load the map sent.
+func (m *handlesBases) InitBases(states []P.ClientBaseState) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.bases) != 0 {
+		return
+	}
+
+	if len(states) > maxBases {
+		log.Printf("got %d bases, only using the first %d", len(states), maxBases)
+		states = states[:maxBases]
+	}
+
+	// bases with the same negative ammo type share the same random ammo type
+	ammoGroups := map[int32]weapon.ID{}
+
+	bases := []*base{}
+	for i, state := range states {
+		ammoType := weapon.ID(state.AmmoType)
+		if ammoType < weapon.Shotgun || ammoType > weapon.Pistol {
+			group := state.AmmoType
+			if group > 0 {
+				group = 0
+			}
+			if existing, ok := ammoGroups[group]; ok && group < 0 {
+				ammoType = existing
+			} else {
+				ammoType = weapon.ID(rng.Intn(int(weapon.Pistol)) + 1)
+				ammoGroups[group] = ammoType
+			}
+		}
+
+		bases = append(bases, &base{
+			index:    int32(i),
+			ammoType: ammoType,
+			position: geom.NewVector(
+				state.Position.X,
+				state.Position.Y,
+				state.Position.Z,
+			),
+		})
+	}
+
+	m.bases = bases
+	m.s.Broadcast(m.basesPacket())
+}
+
+func (m *handlesBases) basesPacket() P.Bases {
+	message := P.Bases{}
+	for _, b := range m.bases {
+		info := b.infoPacket()
+		message.Bases = append(message.Bases, P.BaseState{
+			AmmoType:  int32(b.ammoType),
+			Owner:     info.Owner,
+			Enemy:     info.Enemy,
+			Converted: info.Converted,
+			AmmoCount: info.AmmoCount,
+		})
+	}
+	return message
+}
+
+func (m *handlesBases) BasesInitPacket() []P.Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	messages := []P.Message{}
+	m.ForEachTeam(func(t *Team) {
+		if t.Score != 0 {
+			messages = append(messages, P.BaseScore{
+				Base:  -1,
+				Team:  t.Name,
+				Total: t.Score,
+			})
+		}
+	})
+	return append(messages, m.basesPacket())
+}
+
+// occupants counts the living players of the base owner's team and of each
+// other team standing inside the base.
+func (m *handlesBases) occupants(b *base) (owners int32, others map[*Team]int32) {
+	others = map[*Team]int32{}
+	m.s.ForEachPlayer(func(p *Player) {
+		if p.State != playerstate.Alive || p.Team == NoTeam || !b.contains(p.Position) {
+			return
+		}
+		if p.Team == b.owner {
+			owners++
+		} else {
+			others[p.Team]++
+		}
+	})
+	return
+}
+
+func (m *handlesBases) update() {
+	if len(m.bases) == 0 {
+		return
+	}
+
+	for _, b := range m.bases {
+		owners, others := m.occupants(b)
+
+		// an abandoned conversion is taken over by the next team to enter
+		if b.enemy == nil || others[b.enemy] == 0 {
+			for t, n := range others {
+				if b.enemy == nil || n > others[b.enemy] {
+					if b.enemy != t {
+						b.enemy = t
+						b.converted = 0
+					}
+				}
+			}
+		}
+
+		if b.enemy != nil {
+			enemies := others[b.enemy]
+			if owners == 0 || enemies == 0 {
+				var units int32
+				if enemies > 0 {
+					units = occupyBonus + occupyPoints*enemies
+				} else {
+					units = -(occupyBonus + occupyPoints*(1+owners))
+				}
+				if b.occupy(units) && b.owner != nil {
+					m.s.Broadcast(b.infoPacket())
+					if m.endCheck() {
+						return
+					}
+					continue
+				}
+			}
+			m.s.Broadcast(b.infoPacket())
+		} else if b.owner != nil {
+			b.captureTime++
+			if b.captureTime%scoreSeconds == 0 {
+				m.addScore(b, 1)
+			}
+			if m.regen {
+				if b.captureTime%regenSeconds == 0 {
+					m.regenOwners(b)
+				}
+			} else if b.captureTime%ammoSeconds == 0 && b.ammo < maxBaseAmmo {
+				b.ammo++
+				m.s.Broadcast(b.infoPacket())
+			}
+		}
+	}
+}
+
+func (m *handlesBases) addScore(b *base, n int32) {
+	b.owner.Score += n
+	m.s.Broadcast(P.BaseScore{
+		Base:  b.index,
+		Team:  b.owner.Name,
+		Total: b.owner.Score,
+	})
+}
+
+// endCheck ends the game when a single team owns all bases.
+func (m *handlesBases) endCheck() bool {
+	var winner *Team
+	for _, b := range m.bases {
+		if b.owner == nil || (winner != nil && b.owner != winner) {
+			return false
+		}
+		winner = b.owner
+	}
+
+	winner.Score = winningScore
+	m.s.Broadcast(P.BaseScore{
+		Base:  -1,
+		Team:  winner.Name,
+		Total: winner.Score,
+	})
+	go m.s.Intermission()
+	return true
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func ammoPickup(wpn weapon.ID) entity.Pickup {
+	return entity.Pickups[entity.ID(wpn)+entity.PickupShotgun-1]
+}
+
+func (m *handlesBases) regenOwners(b *base) {
+	maxArmour := entity.Pickups[entity.PickupGreenArmour].MaxAmount
+	ammo := ammoPickup(b.ammoType)
+
+	m.s.ForEachPlayer(func(p *Player) {
+		if p.State != playerstate.Alive || p.Team != b.owner || !b.contains(p.Position) {
+			return
+		}
+
+		notify := false
+		if p.Health < p.MaxHealth {
+			p.Health = minInt32(p.Health+regenHealth, p.MaxHealth)
+			notify = true
+		}
+		if p.Armour < maxArmour {
+			p.Armour = minInt32(p.Armour+regenArmour, maxArmour)
+			notify = true
+		}
+		if p.Ammo[b.ammoType] < ammo.MaxAmount {
+			p.Ammo[b.ammoType] = minInt32(p.Ammo[b.ammoType]+maxInt32(ammo.Amount*regenAmmo/100, 1), ammo.MaxAmount)
+			notify = true
+		}
+
+		if notify {
+			m.s.Broadcast(P.BaseRegen{
+				Client:   int32(p.CN),
+				Health:   p.Health,
+				Armour:   p.Armour,
+				Ammotype: int32(b.ammoType),
+				Ammo:     p.Ammo[b.ammoType],
+			})
+		}
+	})
+}
+
+func (m *handlesBases) replenishAmmo(p *Player) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if p.State != playerstate.Alive || m.regen {
+		return
+	}
+
+	for _, b := range m.bases {
+		ammo := ammoPickup(b.ammoType)
+		if b.owner != p.Team || b.ammo <= 0 || !b.contains(p.Position) || p.Ammo[b.ammoType] >= ammo.MaxAmount {
+			continue
+		}
+
+		b.ammo--
+		p.Ammo[b.ammoType] = minInt32(p.Ammo[b.ammoType]+ammo.Amount, ammo.MaxAmount)
+		m.s.Broadcast(
+			b.infoPacket(),
+			P.ReplenishAmmo{
+				Client:   int32(p.CN),
+				Ammotype: int32(b.ammoType),
+			},
+		)
+		return
+	}
+}
+
+func (m *handlesBases) Pause() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.Pause()
+}
+
+func (m *handlesBases) Resume() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.Start()
+}
+
+func (m *handlesBases) SetSpeed(speed int32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.SetSpeed(speed)
+}
+
+func (m *handlesBases) CleanUp() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.cleanedUp = true
+	m.nextUpdate.Stop()
+}
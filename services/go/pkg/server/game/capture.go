@@ -1,18 +1,49 @@
 package game
 
-type CaptureMode interface {
-	TeamMode
-	Bases(*Team) []int32
+import (
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+)
+
+type Capture struct {
+	captureSpawnState
+	fiveSecondsSpawnWait
+	*handlesBases
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode        = &Capture{}
+	_ HasTimers   = &Capture{}
+	_ TeamMode    = &Capture{}
+	_ CaptureMode = &Capture{}
+)
+
+func NewCapture(s Server, keepTeams bool) *Capture {
+	return &Capture{
+		handlesBases: handlingBases(s, keepTeams, false),
+	}
 }
 
-type captureMode struct {
-	teamMode
-	bases map[*Team][]int32
+func (*Capture) ID() gamemode.ID { return gamemode.Capture }
+
+type RegenCapture struct {
+	captureSpawnState
+	noSpawnWait
+	*handlesBases
 }
 
-func (cm *captureMode) Bases(t *Team) []int32 {
-	if bases, ok := cm.bases[t]; ok {
-		return bases
+// assert interface implementations at compile time
+var (
+	_ Mode        = &RegenCapture{}
+	_ HasTimers   = &RegenCapture{}
+	_ TeamMode    = &RegenCapture{}
+	_ CaptureMode = &RegenCapture{}
+)
+
+func NewRegenCapture(s Server, keepTeams bool) *RegenCapture {
+	return &RegenCapture{
+		handlesBases: handlingBases(s, keepTeams, true),
 	}
-	return nil
 }
+
+func (*RegenCapture) ID() gamemode.ID { return gamemode.RegenCapture }
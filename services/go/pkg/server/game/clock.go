@@ -19,6 +19,7 @@ type Clock interface {
 	Ended() bool
 	TimeLeft() time.Duration
 	SetTimeLeft(time.Duration)
+	SetSpeed(int32)
 	Leave(*Player)
 	CleanUp()
 }
@@ -34,7 +35,7 @@ var _ Clock = &casualClock{}
 func NewCasualClock(s Server, m HasTimers) *casualClock {
 	return &casualClock{
 		s:          s,
-		t:          timer.AfterFunc(s.GameDuration(), s.Intermission),
+		t:          gameTimer(s, s.GameDuration(), s.Intermission),
 		modeTimers: m,
 	}
 }
@@ -87,6 +88,8 @@ func (c *casualClock) Leave(*Player) {}
 func (c *casualClock) Stop() {
 	c.s.Broadcast(P.TimeUp{0})
 	c.t.Stop()
+	// nothing should happen during intermission
+	c.modeTimers.Pause()
 }
 
 func (c *casualClock) Ended() bool {
@@ -104,6 +107,11 @@ func (c *casualClock) SetTimeLeft(d time.Duration) {
 	c.s.Broadcast(P.TimeUp{int32(d / time.Second)})
 }
 
+func (c *casualClock) SetSpeed(speed int32) {
+	c.t.SetSpeed(speed)
+	c.modeTimers.SetSpeed(speed)
+}
+
 func (c *casualClock) CleanUp() {
 	c.t.Stop()
 	c.modeTimers.CleanUp()
@@ -152,6 +160,10 @@ func (c *endlessClock) TimeLeft() time.Duration {
 func (c *endlessClock) SetTimeLeft(d time.Duration) {
 }
 
+func (c *endlessClock) SetSpeed(speed int32) {
+	c.modeTimers.SetSpeed(speed)
+}
+
 func (c *endlessClock) CleanUp() {
 	c.modeTimers.CleanUp()
 }
@@ -159,12 +171,19 @@ func (c *endlessClock) CleanUp() {
 type Competitive interface {
 	Clock
 	Spawned(*Player)
+	Hold()
+	Release() bool
+	Overtime(time.Duration)
 }
 
 type competitiveClock struct {
 	*casualClock
 	pendingResumeActions []*time.Timer
 	mapLoadPending       map[*Player]struct{}
+	// set while the game must not start, even if all players spawned
+	held bool
+	// whether the game started after everyone loaded the map
+	started bool
 }
 
 var (
@@ -189,17 +208,52 @@ func (c *competitiveClock) Start() {
 	if len(c.mapLoadPending) > 0 {
 		c.s.Message("waiting for all players to load the map")
 		c.Pause(nil)
+		return
+	}
+	c.startMatch()
+}
+
+// Lets round-based modes start their first round once the game starts.
+func (c *competitiveClock) startMatch() {
+	if c.started {
+		return
+	}
+	c.started = true
+	if rounds, ok := c.modeTimers.(RoundMode); ok {
+		rounds.StartMatch()
 	}
 }
 
 func (c *competitiveClock) Spawned(p *Player) {
 	delete(c.mapLoadPending, p)
-	if len(c.mapLoadPending) == 0 {
+	if len(c.mapLoadPending) == 0 && !c.held {
 		c.s.Message("all players spawned, starting game")
 		c.Resume(nil)
 	}
 }
 
+// Hold pauses the game and keeps it paused once all players spawned, until
+// Release is called.
+func (c *competitiveClock) Hold() {
+	c.held = true
+	c.casualClock.Pause(nil)
+}
+
+// Release undoes Hold. It returns true if all players spawned already, in
+// which case the game has to be resumed by the caller.
+func (c *competitiveClock) Release() bool {
+	c.held = false
+	return len(c.mapLoadPending) == 0
+}
+
+// Overtime restarts the clock after the time ran out, with d left to play.
+func (c *competitiveClock) Overtime(d time.Duration) {
+	c.t = gameTimer(c.s, d, c.s.Intermission)
+	// overtime passes at the same game speed as the rest of the match
+	c.t.SetSpeed(c.s.GameSpeed())
+	c.casualClock.Start()
+}
+
 func (c *competitiveClock) Pause(p *Player) {
 	if !c.t.Paused() {
 		c.casualClock.Pause(p)
@@ -231,6 +285,7 @@ func (c *competitiveClock) Resume(p *Player) {
 		time.AfterFunc(3*time.Second, func() {
 			c.casualClock.Resume(p)
 			c.pendingResumeActions = nil
+			c.startMatch()
 		}),
 	}
 }
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"log"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+)
+
+type Collect struct {
+	ctfSpawnState
+	fiveSecondsSpawnWait
+	*handlesTokens
+	*handlesPickups
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode        = &Collect{}
+	_ HasTimers   = &Collect{}
+	_ TeamMode    = &Collect{}
+	_ CollectMode = &Collect{}
+	_ PickupMode  = &Collect{}
+)
+
+func NewCollect(s Server, keepTeams bool) *Collect {
+	return &Collect{
+		handlesTokens:  handlingTokens(s, keepTeams),
+		handlesPickups: handlingPickups(s),
+	}
+}
+
+func (m *Collect) NeedsMapInfo() bool {
+	return m.handlesPickups.NeedsMapInfo() || m.handlesTokens.NeedsMapInfo()
+}
+
+func (m *Collect) HandlePacket(p *Player, message P.Message) bool {
+	switch message.Type() {
+
+	case P.N_INITTOKENS, P.N_TAKETOKEN, P.N_DEPOSITTOKENS:
+		return m.handlesTokens.HandlePacket(p, message)
+
+	case P.N_ITEMLIST, P.N_ITEMPICKUP:
+		return m.handlesPickups.HandlePacket(p, message)
+	default:
+		log.Println("received unrelated packet", message)
+		return false
+	}
+}
+
+func (m *Collect) Pause() {
+	m.handlesTokens.Pause()
+	m.handlesPickups.Pause()
+}
+
+func (m *Collect) Resume() {
+	m.handlesTokens.Resume()
+	m.handlesPickups.Resume()
+}
+
+func (m *Collect) SetSpeed(speed int32) {
+	m.handlesTokens.SetSpeed(speed)
+	m.handlesPickups.SetSpeed(speed)
+}
+
+func (m *Collect) CleanUp() {
+	m.handlesTokens.CleanUp()
+	m.handlesPickups.CleanUp()
+}
+
+func (*Collect) ID() gamemode.ID { return gamemode.Collect }
+
+type InstaCollect struct {
+	instaSpawnState
+	fiveSecondsSpawnWait
+	*handlesTokens
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode        = &InstaCollect{}
+	_ HasTimers   = &InstaCollect{}
+	_ TeamMode    = &InstaCollect{}
+	_ CollectMode = &InstaCollect{}
+)
+
+func NewInstaCollect(s Server, keepTeams bool) *InstaCollect {
+	return &InstaCollect{
+		handlesTokens: handlingTokens(s, keepTeams),
+	}
+}
+
+func (*InstaCollect) ID() gamemode.ID { return gamemode.InstaCollect }
+
+type EfficCollect struct {
+	efficSpawnState
+	fiveSecondsSpawnWait
+	*handlesTokens
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode        = &EfficCollect{}
+	_ HasTimers   = &EfficCollect{}
+	_ TeamMode    = &EfficCollect{}
+	_ CollectMode = &EfficCollect{}
+)
+
+func NewEfficCollect(s Server, keepTeams bool) *EfficCollect {
+	return &EfficCollect{
+		handlesTokens: handlingTokens(s, keepTeams),
+	}
+}
+
+func (*EfficCollect) ID() gamemode.ID { return gamemode.EfficCollect }
@@ -73,6 +73,11 @@ func (m *CTF) Resume() {
 	m.handlesPickups.Resume()
 }
 
+func (m *CTF) SetSpeed(speed int32) {
+	m.ctfMode.SetSpeed(speed)
+	m.handlesPickups.SetSpeed(speed)
+}
+
 func (m *CTF) CleanUp() {
 	m.ctfMode.CleanUp()
 	m.handlesPickups.CleanUp()
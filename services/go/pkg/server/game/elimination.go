@@ -0,0 +1,303 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/timer"
+)
+
+// In elimination, everyone gets one life per round. Dead players spectate
+// until the round is over, and the last player (or team) standing wins the
+// round and scores a point. Kills don't score. Elimination is played with
+// the rules of an instagib, efficiency or tactics mode, which is what clients
+// are told is being played. Like competitive games, it waits for all players
+// to load the map, during which they spawn like in any other mode, and
+// pauses when a player leaves.
+
+// The server functions round-based modes need.
+type RoundServer interface {
+	Server
+	// respawns everyone that isn't spectating
+	StartRound()
+	// kills everyone and resets their scores, if resetFrags is set
+	ResetPlayers(resetFrags bool)
+}
+
+// Modes played in rounds. They're played on a competitive clock, which calls
+// StartMatch once all players loaded the map.
+type RoundMode interface {
+	Mode
+	// starts the first round with everyone's scores reset
+	StartMatch()
+	// whether the player is out until the next round starts
+	Eliminated(*Player) bool
+}
+
+const (
+	// the pause between two rounds
+	roundBreak = 5 * time.Second
+	// how long after a round started players that just joined may still
+	// spawn
+	lateJoin = 5 * time.Second
+)
+
+// Whether elimination can be played with the rules of the given mode.
+func CanEliminate(id gamemode.ID) bool {
+	switch id {
+	case gamemode.Insta, gamemode.Effic, gamemode.Tactics,
+		gamemode.InstaTeam, gamemode.EfficTeam, gamemode.TacticsTeam:
+		return true
+	default:
+		return false
+	}
+}
+
+type Elimination struct {
+	Mode
+	s     RoundServer
+	teams bool
+
+	mutex sync.Mutex
+	// whether the first round started
+	started      bool
+	round        int
+	roundStarted time.Time
+	// players that died this round and now spectate
+	eliminated map[*Player]struct{}
+	nextRound  *timer.Timer
+}
+
+var _ RoundMode = &Elimination{}
+
+func NewElimination(s RoundServer, m Mode) *Elimination {
+	return &Elimination{
+		Mode:         m,
+		s:            s,
+		round:        1,
+		roundStarted: time.Now(),
+		eliminated:   map[*Player]struct{}{},
+	}
+}
+
+// Players can only spawn when a round starts.
+func (m *Elimination) CanSpawn(p *Player) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// players spawn to tell the clock they loaded the map
+	if !m.started {
+		return m.Mode.CanSpawn(p)
+	}
+
+	_, eliminated := m.eliminated[p]
+	return !eliminated && m.nextRound == nil && time.Since(m.roundStarted) < lateJoin
+}
+
+func (m *Elimination) HandleFrag(actor, victim *Player) {
+	victim.Die()
+	switch {
+	case actor == victim:
+		actor.Stats.Suicides++
+	case actor.Team != NoTeam && actor.Team == victim.Team:
+		actor.Teamkills++
+	default:
+		actor.Stats.frag()
+	}
+	m.s.Broadcast(P.Died{
+		Client:      int32(victim.CN),
+		Killer:      int32(actor.CN),
+		KillerFrags: actor.Frags,
+		VictimFrags: actor.Team.Frags,
+	})
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.eliminated[victim] = struct{}{}
+	victim.State = playerstate.Spectator
+	m.s.Broadcast(P.Spectator{Client: int32(victim.CN), Spectating: true})
+
+	m.checkRound(nil)
+}
+
+func (m *Elimination) Leave(p *Player) {
+	m.Mode.Leave(p)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.eliminated, p)
+	m.checkRound(p)
+}
+
+func (m *Elimination) Eliminated(p *Player) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, eliminated := m.eliminated[p]
+	return eliminated
+}
+
+// whether the player is (about to be) alive in this round
+func inRound(p *Player) bool {
+	return p.State == playerstate.Alive || (p.State == playerstate.Dead && !p.LastSpawnAttempt.IsZero())
+}
+
+// Ends the round once only one player or team is left, not counting the
+// player that is leaving, if any.
+func (m *Elimination) checkRound(leaving *Player) {
+	if !m.started || m.nextRound != nil {
+		return
+	}
+
+	var lastPlayer *Player
+	survivors := map[*Team]int{}
+	players := 0
+	m.s.ForEachPlayer(func(p *Player) {
+		if p == leaving || !inRound(p) {
+			return
+		}
+		lastPlayer = p
+		survivors[p.Team]++
+		players++
+	})
+
+	sides := players
+	if m.teams {
+		sides = len(survivors)
+	}
+	if sides > 1 {
+		return
+	}
+
+	switch {
+	case sides == 0:
+		m.s.Message(fmt.Sprintf("round %d is a draw", m.round))
+	case m.teams:
+		team := lastPlayer.Team
+		team.Frags++
+		m.s.Broadcast(P.TeamInfo{Teams: []P.Team{{Team: team.Name, Frags: team.Frags}}})
+		m.s.Message(fmt.Sprintf("team %s wins round %d", team.Name, m.round))
+	default:
+		lastPlayer.Frags++
+		m.s.Broadcast(P.Resume{Clients: []P.ClientState{{
+			Id:          int32(lastPlayer.CN),
+			State:       int32(lastPlayer.State),
+			Frags:       lastPlayer.Frags,
+			Flags:       lastPlayer.Flags,
+			Deaths:      lastPlayer.Deaths,
+			Quadmillis:  int32(lastPlayer.QuadTimer.TimeLeft() / time.Millisecond),
+			EntityState: lastPlayer.ToWire(),
+		}}})
+		m.s.Message(fmt.Sprintf("%s wins round %d", m.s.UniqueName(lastPlayer), m.round))
+	}
+
+	m.nextRound = gameTimer(m.s, roundBreak, m.startRound)
+	m.nextRound.Start()
+}
+
+// Brings the eliminated players back.
+func (m *Elimination) revive() {
+	for p := range m.eliminated {
+		if p.State == playerstate.Spectator {
+			p.State = playerstate.Dead
+			m.s.Broadcast(P.Spectator{Client: int32(p.CN), Spectating: false})
+		}
+	}
+	m.eliminated = map[*Player]struct{}{}
+}
+
+func (m *Elimination) StartMatch() {
+	m.mutex.Lock()
+	m.started = true
+	m.round = 1
+	m.roundStarted = time.Now()
+	m.mutex.Unlock()
+
+	m.s.ResetPlayers(true)
+	m.s.Message(fmt.Sprintf("round %d", m.round))
+	m.s.StartRound()
+}
+
+func (m *Elimination) startRound() {
+	m.mutex.Lock()
+	m.revive()
+	m.round++
+	m.roundStarted = time.Now()
+	m.nextRound = nil
+	m.mutex.Unlock()
+
+	m.s.Message(fmt.Sprintf("round %d", m.round))
+	m.s.StartRound()
+}
+
+func (m *Elimination) Pause() {
+	m.Mode.Pause()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.nextRound != nil {
+		m.nextRound.Pause()
+	}
+}
+
+func (m *Elimination) Resume() {
+	m.Mode.Resume()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.nextRound != nil {
+		m.nextRound.Start()
+	}
+}
+
+func (m *Elimination) SetSpeed(speed int32) {
+	m.Mode.SetSpeed(speed)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.nextRound != nil {
+		m.nextRound.SetSpeed(speed)
+	}
+}
+
+func (m *Elimination) CleanUp() {
+	m.Mode.CleanUp()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.nextRound != nil {
+		m.nextRound.Stop()
+	}
+	// nobody should stay a spectator because they died in the last round
+	m.revive()
+}
+
+// Elimination for teams: the last team with players standing wins the round.
+type TeamElimination struct {
+	*Elimination
+	TeamMode
+}
+
+var (
+	_ RoundMode = &TeamElimination{}
+	_ TeamMode  = &TeamElimination{}
+)
+
+func NewTeamElimination(s RoundServer, m Mode, teamMode TeamMode) *TeamElimination {
+	elimination := NewElimination(s, m)
+	elimination.teams = true
+	return &TeamElimination{
+		Elimination: elimination,
+		TeamMode:    teamMode,
+	}
+}
+
+func (m *TeamElimination) HandleFrag(actor, victim *Player) {
+	m.Elimination.HandleFrag(actor, victim)
+}
+
+func (m *TeamElimination) Leave(p *Player) {
+	// Elimination's Leave also makes the player leave their team
+	m.Elimination.Leave(p)
+}
@@ -11,6 +11,15 @@ import (
 	"github.com/cfoust/sour/pkg/server/timer"
 )
 
+// values taken from ctf.h in the vanilla game
+const (
+	flagLimit     = 10
+	resetFlagTime = 10 * time.Second
+	invisFlagTime = 20 * time.Second
+	holdFlagTime  = 20 * time.Second
+	respawnTime   = 5 * time.Second
+)
+
 type FlagMode interface {
 	NeedsMapInfo() bool
 	FlagsInitPacket() protocol.Message
@@ -19,7 +28,9 @@ type FlagMode interface {
 type flagMode interface {
 	TeamMode
 	CanSpawn(*Player) bool
-	InitFlags([]*flag) bool
+	// InitFlags receives the flags the client found in the map and returns
+	// the ones that are actually in play.
+	InitFlags([]*flag) ([]*flag, bool)
 	TouchFlag(*Player, *flag)
 	DropFlag(*Player, *flag)
 	TeamByFlagTeamID(int32) *Team
@@ -35,6 +46,23 @@ type flag struct {
 	dropLocation  *geom.Vector
 	dropTime      time.Time
 	pendingReset  *timer.Timer
+	// only used in hold mode, where the flag moves between spawn locations
+	spawnIndex   int32
+	pendingScore *timer.Timer
+	// only used in protect mode, where a flag disappears for a while after
+	// the enemy scored on it
+	invisible     bool
+	pendingReveal *timer.Timer
+}
+
+func (f *flag) timers() []*timer.Timer {
+	timers := []*timer.Timer{}
+	for _, t := range []*timer.Timer{f.pendingReset, f.pendingScore, f.pendingReveal} {
+		if t != nil {
+			timers = append(timers, t)
+		}
+	}
+	return timers
 }
 
 type handlesFlags struct {
@@ -102,7 +130,7 @@ func (m *handlesFlags) initFlags(message protocol.ClientInitFlags) {
 		return
 	}
 
-	ok := m.InitFlags(flags)
+	flags, ok := m.InitFlags(flags)
 	if ok {
 		m.flags = flags
 	}
@@ -142,12 +170,16 @@ func (m *handlesFlags) dropAllFlags(p *Player) {
 func (m *handlesFlags) FlagsInitPacket() protocol.Message {
 	message := protocol.ServerInitFlags{}
 
-	for i, f := range m.flags {
-		if f == nil || f.team == nil {
-			continue
+	for i := range message.Scores {
+		if team := m.TeamByFlagTeamID(int32(i + 1)); team != nil {
+			message.Scores[i].Score = team.Score
 		}
+	}
 
-		message.Scores[i].Score = f.team.Score
+	for _, f := range m.flags {
+		if f == nil {
+			continue
+		}
 
 		var carrierCN int32 = -1
 		if f.carrier != nil {
@@ -156,9 +188,9 @@ func (m *handlesFlags) FlagsInitPacket() protocol.Message {
 
 		flagState := protocol.FlagState{
 			Version:   f.version,
-			Spawn:     0,
+			Spawn:     f.spawnIndex,
 			Owner:     int32(carrierCN),
-			Invisible: false,
+			Invisible: f.invisible,
 		}
 
 		if f.carrier == nil {
@@ -185,19 +217,40 @@ func (m *handlesFlags) HandleFrag(actor, victim *Player) {
 
 func (m *handlesFlags) Pause() {
 	for _, f := range m.flags {
-		if f == nil || f.pendingReset == nil || f.pendingReset.TimeLeft() == 0 {
+		if f == nil {
 			continue
 		}
-		f.pendingReset.Pause()
+		for _, t := range f.timers() {
+			if t.TimeLeft() == 0 {
+				continue
+			}
+			t.Pause()
+		}
 	}
 }
 
 func (m *handlesFlags) Resume() {
 	for _, f := range m.flags {
-		if f == nil || f.pendingReset == nil || f.pendingReset.TimeLeft() == 0 {
+		if f == nil {
 			continue
 		}
-		f.pendingReset.Start()
+		for _, t := range f.timers() {
+			if t.TimeLeft() == 0 {
+				continue
+			}
+			t.Start()
+		}
+	}
+}
+
+func (m *handlesFlags) SetSpeed(speed int32) {
+	for _, f := range m.flags {
+		if f == nil {
+			continue
+		}
+		for _, t := range f.timers() {
+			t.SetSpeed(speed)
+		}
 	}
 }
 
@@ -208,9 +261,11 @@ func (m *handlesFlags) Leave(p *Player) {
 
 func (m *handlesFlags) CleanUp() {
 	for _, f := range m.flags {
-		if f == nil || f.pendingReset == nil {
+		if f == nil {
 			continue
 		}
-		f.pendingReset.Stop()
+		for _, t := range f.timers() {
+			t.Stop()
+		}
 	}
 }
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	P "github.com/cfoust/sour/pkg/game/protocol"
-	"github.com/cfoust/sour/pkg/server/timer"
 )
 
 type ctf struct {
@@ -40,10 +39,21 @@ func (m *ctf) TeamByFlagTeamID(i int32) *Team {
 	}
 }
 
-func (m *ctf) InitFlags(flags []*flag) bool {
+func (m *ctf) FlagTeamIDByTeam(t *Team) int32 {
+	switch t {
+	case m.good:
+		return 1
+	case m.evil:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func (m *ctf) InitFlags(flags []*flag) ([]*flag, bool) {
 	if len(flags) != 2 {
 		log.Printf("expected 2 flags in CTF mode, but got %d", len(flags))
-		return false
+		return nil, false
 	}
 
 	for _, f := range flags {
@@ -54,13 +64,13 @@ func (m *ctf) InitFlags(flags []*flag) bool {
 			m.evilFlag = f
 		default:
 			log.Printf("flag %v can't be matched to either good or evil", f)
-			return false
+			return nil, false
 		}
 	}
 
 	m.initialized = true
 
-	return true
+	return flags, true
 }
 
 func (m *ctf) TouchFlag(p *Player, f *flag) {
@@ -71,6 +81,7 @@ func (m *ctf) TouchFlag(p *Player, f *flag) {
 		// player touches her own, dropped flag
 		f.pendingReset.Stop()
 		m.returnFlag(f)
+		p.Stats.FlagsReturned++
 		m.s.Broadcast(P.ReturnFlag{int32(p.CN), f.index, f.version})
 		return
 	} else {
@@ -102,7 +113,7 @@ func (m *ctf) TouchFlag(p *Player, f *flag) {
 			p.Team.Score,
 			p.Flags,
 		})
-		if p.Team.Score >= 10 {
+		if p.Team.Score >= flagLimit {
 			m.s.Intermission()
 		}
 	}
@@ -116,6 +127,7 @@ func (m *ctf) takeFlag(p *Player, f *flag) {
 	}
 
 	f.version++
+	p.Stats.FlagsTaken++
 	m.s.Broadcast(P.ServerTakeFlag{
 		Client:  int32(p.CN),
 		Flag:    f.index,
@@ -147,7 +159,7 @@ func (m *ctf) DropFlag(p *Player, f *flag) {
 		},
 	})
 
-	f.pendingReset = timer.AfterFunc(10*time.Second, func() {
+	f.pendingReset = gameTimer(m.s, resetFlagTime, func() {
 		m.returnFlag(f)
 		m.s.Broadcast(P.ResetFlag{
 			f.index,
@@ -161,5 +173,5 @@ func (m *ctf) DropFlag(p *Player, f *flag) {
 }
 
 func (m *ctf) CanSpawn(p *Player) bool {
-	return p.LastDeath.IsZero() || time.Since(p.LastDeath) > 5*time.Second
+	return p.LastDeath.IsZero() || time.Since(p.LastDeath) > respawnTime
 }
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/geom"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// In hold mode, there is a single neutral flag that spawns at one of the
+// neutral flag locations in the map. Teams score by holding it long enough.
+type hold struct {
+	*teamMode
+	s      Server
+	good   *Team
+	evil   *Team
+	spawns []*geom.Vector
+	// the flag's timers run in their own goroutines
+	mutex deadlock.Mutex
+}
+
+var _ flagMode = &hold{}
+
+func newHold(s Server, m *teamMode, good, evil *Team) *hold {
+	return &hold{
+		s:        s,
+		teamMode: m,
+		good:     good,
+		evil:     evil,
+	}
+}
+
+func (m *hold) TeamByFlagTeamID(i int32) *Team {
+	switch i {
+	case 1:
+		return m.good
+	case 2:
+		return m.evil
+	default:
+		return nil
+	}
+}
+
+func (m *hold) flagTeamID(t *Team) int32 {
+	switch t {
+	case m.good:
+		return 1
+	case m.evil:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// InitFlags uses the neutral flags in the map as the possible spawn
+// locations of the flag. Team flags are only used in CTF and protect.
+func (m *hold) InitFlags(spawns []*flag) ([]*flag, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.spawns = make([]*geom.Vector, 0, len(spawns))
+	for _, s := range spawns {
+		if s.teamID == 0 {
+			m.spawns = append(m.spawns, s.spawnLocation)
+		}
+	}
+
+	if len(m.spawns) == 0 {
+		log.Println("expected at least one neutral flag spawn in hold mode, but got none")
+		return nil, false
+	}
+
+	f := &flag{index: 0}
+	m.spawnFlag(f)
+	m.s.Broadcast(P.ResetFlag{
+		Flag:    f.index,
+		Version: f.version,
+		Spawn:   f.spawnIndex,
+		Team:    0,
+		Score:   0,
+	})
+
+	return []*flag{f}, true
+}
+
+// spawnFlag moves the flag to a new, random spawn location.
+func (m *hold) spawnFlag(f *flag) {
+	i := int32(rng.Intn(len(m.spawns)))
+	if len(m.spawns) > 1 && i == f.spawnIndex {
+		i = (i + 1 + int32(rng.Intn(len(m.spawns)-1))) % int32(len(m.spawns))
+	}
+
+	f.spawnIndex = i
+	f.spawnLocation = m.spawns[i]
+	f.dropTime = time.Time{}
+	f.carrier = nil
+	f.version++
+}
+
+func (m *hold) TouchFlag(p *Player, f *flag) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.flagTeamID(p.Team) == 0 || f.carrier != nil {
+		return
+	}
+
+	// cancel reset
+	if f.pendingReset != nil {
+		f.pendingReset.Stop()
+		f.pendingReset = nil
+	}
+
+	f.dropTime = time.Time{}
+	f.version++
+	p.Stats.FlagsTaken++
+	m.s.Broadcast(P.ServerTakeFlag{
+		Client:  int32(p.CN),
+		Flag:    f.index,
+		Version: f.version,
+	})
+	f.carrier = p
+
+	version := f.version
+	f.pendingScore = gameTimer(m.s, holdFlagTime, func() {
+		m.scoreFlag(p, f, version)
+	})
+	f.pendingScore.Start()
+}
+
+func (m *hold) scoreFlag(p *Player, f *flag, version int32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// the flag might have been dropped while we waited for the lock
+	if f.carrier != p || f.version != version {
+		return
+	}
+	f.pendingScore = nil
+
+	p.Flags++
+	p.Team.Score++
+	m.spawnFlag(f)
+	m.s.Broadcast(P.ScoreFlag{
+		Client:       int32(p.CN),
+		Relayflag:    -1,
+		Relayversion: -1,
+		Goalflag:     f.index,
+		Goalversion:  f.version,
+		Goalspawn:    f.spawnIndex,
+		Team:         m.flagTeamID(p.Team),
+		Score:        p.Team.Score,
+		Oflags:       p.Flags,
+	})
+
+	if p.Team.Score >= flagLimit {
+		// we're running in the timer's goroutine, intermission will stop it
+		go m.s.Intermission()
+	}
+}
+
+func (m *hold) DropFlag(p *Player, f *flag) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if f.carrier != p {
+		return
+	}
+
+	if f.pendingScore != nil {
+		f.pendingScore.Stop()
+		f.pendingScore = nil
+	}
+
+	f.dropLocation = p.Position
+	f.dropTime = time.Now()
+	f.carrier = nil
+	f.version++
+
+	m.s.Broadcast(P.DropFlag{
+		Client:  int32(p.CN),
+		Flag:    f.index,
+		Version: f.version,
+		Position: P.Vec{
+			X: f.dropLocation.X(),
+			Y: f.dropLocation.Y(),
+			Z: f.dropLocation.Z(),
+		},
+	})
+
+	version := f.version
+	f.pendingReset = gameTimer(m.s, resetFlagTime, func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		// someone might have taken the flag while we waited for the lock
+		if f.version != version {
+			return
+		}
+		f.pendingReset = nil
+		m.spawnFlag(f)
+		m.s.Broadcast(P.ResetFlag{
+			Flag:    f.index,
+			Version: f.version,
+			Spawn:   f.spawnIndex,
+			Team:    0,
+			Score:   0,
+		})
+	})
+	f.pendingReset.Start()
+}
+
+func (m *hold) CanSpawn(p *Player) bool {
+	return p.LastDeath.IsZero() || time.Since(p.LastDeath) > respawnTime
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	P "github.com/cfoust/sour/pkg/game/protocol"
+)
+
+// In protect mode, players carry their own flag around to keep it safe and
+// score by touching the enemy flag.
+type protect struct {
+	*ctf
+}
+
+var _ flagMode = &protect{}
+
+func newProtect(s Server, m *teamMode, good, evil *Team) *protect {
+	return &protect{
+		ctf: newCTF(s, m, good, evil),
+	}
+}
+
+func (m *protect) TouchFlag(p *Player, f *flag) {
+	if p.Team == f.team {
+		// player picks up her own flag, wherever it is
+		m.takeFlag(p, f)
+		return
+	}
+
+	if f.invisible {
+		return
+	}
+
+	m.scoreFlag(p, f)
+}
+
+func (m *protect) scoreFlag(p *Player, f *flag) {
+	if f.pendingReset != nil {
+		f.pendingReset.Stop()
+		f.pendingReset = nil
+	}
+	m.returnFlag(f)
+
+	p.Flags++
+	p.Team.Score++
+	m.s.Broadcast(P.ScoreFlag{
+		Client:       int32(p.CN),
+		Relayflag:    -1,
+		Relayversion: -1,
+		Goalflag:     f.index,
+		Goalversion:  f.version,
+		Goalspawn:    0,
+		Team:         m.FlagTeamIDByTeam(p.Team),
+		Score:        p.Team.Score,
+		Oflags:       p.Flags,
+	})
+
+	// the flag stays hidden for a while so it can't be farmed
+	f.invisible = true
+	m.s.Broadcast(P.InvisFlag{Flag: f.index, Invisible: 1})
+	f.pendingReveal = gameTimer(m.s, invisFlagTime, func() {
+		f.invisible = false
+		m.s.Broadcast(P.InvisFlag{Flag: f.index, Invisible: 0})
+	})
+	f.pendingReveal.Start()
+
+	if p.Team.Score >= flagLimit {
+		m.s.Intermission()
+	}
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"fmt"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/armour"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+)
+
+// In gun game (or arms race), players only get one weapon at a time and every
+// frag moves them up a ladder of weapons. They get the next one right away,
+// which the game only allows by spawning them again: they are moved to a
+// spawn point and lose quad damage and their projectiles. The first player to get
+// a frag with the last weapon wins the match. Suicides move players back
+// down. A player's frags are their position on the ladder, so clients show
+// the standings on the scoreboard.
+
+var DefaultGunGameLadder = []weapon.ID{
+	weapon.Minigun,
+	weapon.Shotgun,
+	weapon.Rifle,
+	weapon.RocketLauncher,
+	weapon.GrenadeLauncher,
+	weapon.Pistol,
+	weapon.Saw,
+}
+
+// Whether gun game can be played instead of the given mode.
+func CanPlayGunGame(id gamemode.ID) bool {
+	switch id {
+	case gamemode.FFA, gamemode.Insta, gamemode.Effic, gamemode.Tactics:
+		return true
+	default:
+		return false
+	}
+}
+
+type GunGame struct {
+	*deathmatch
+	// there are no pickups
+	noMapInfo
+	noTimers
+
+	id     gamemode.ID
+	ladder []weapon.ID
+	// set once someone won
+	over bool
+}
+
+var _ Mode = &GunGame{}
+
+// NewGunGame returns gun game with the given ladder, which clients are told
+// is the mode with the given ID.
+func NewGunGame(s Server, id gamemode.ID, ladder []weapon.ID) *GunGame {
+	if len(ladder) == 0 {
+		ladder = DefaultGunGameLadder
+	}
+	return &GunGame{
+		deathmatch: newDeathmatch(withoutTeams(s)),
+		id:         id,
+		ladder:     ladder,
+	}
+}
+
+func (m *GunGame) ID() gamemode.ID { return m.id }
+
+// the player's position on the ladder
+func (m *GunGame) level(frags int32) int {
+	if frags < 0 {
+		return 0
+	}
+	if int(frags) >= len(m.ladder) {
+		return len(m.ladder) - 1
+	}
+	return int(frags)
+}
+
+func (m *GunGame) Spawn(ps *PlayerState) {
+	ps.ArmourType = armour.Green
+	ps.Armour = 100
+	ps.Ammo, ps.SelectedWeapon = weapon.SpawnAmmoGunGame(m.ladder[m.level(ps.Frags)])
+	ps.Health = ps.MaxHealth
+}
+
+func (m *GunGame) HandleFrag(actor, victim *Player) {
+	victim.Die()
+
+	last := len(m.ladder) - 1
+	won := false
+	switch {
+	case actor == victim:
+		if actor.Frags > 0 {
+			actor.Frags--
+		}
+		actor.Stats.Suicides++
+	default:
+		won = m.level(actor.Frags) == last && victim.DamagedBy == m.ladder[last]
+		actor.Frags++
+		actor.Stats.frag()
+	}
+
+	m.s.Broadcast(P.Died{
+		Client:      int32(victim.CN),
+		Killer:      int32(actor.CN),
+		KillerFrags: actor.Frags,
+		VictimFrags: actor.Team.Frags,
+	})
+
+	name := m.s.UniqueName(actor)
+	level := m.level(actor.Frags)
+	switch {
+	case m.over:
+	case won:
+		m.over = true
+		m.s.Message(fmt.Sprintf("%s won with the %s!", name, m.ladder[last]))
+		m.s.Intermission()
+	case actor == victim:
+		m.s.Message(fmt.Sprintf("%s is back to the %s (%d/%d)", name, m.ladder[level], level+1, len(m.ladder)))
+	case int(actor.Frags) <= last:
+		m.s.Message(fmt.Sprintf("%s advanced to the %s (%d/%d)", name, m.ladder[level], level+1, len(m.ladder)))
+		// the next weapon is theirs as soon as they earned it
+		m.s.Rearm(actor)
+	}
+}
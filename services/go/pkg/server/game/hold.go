@@ -0,0 +1,126 @@
+package game
+
+import (
+	"log"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+)
+
+type holdMode = handlesFlags
+
+func newHoldMode(s Server, keepTeams bool) *holdMode {
+	good, evil := NewTeam("good"), NewTeam("evil")
+	return handlingFlags(
+		newHold(
+			s,
+			withTeams(s, false, keepTeams, good, evil),
+			good,
+			evil,
+		),
+	)
+}
+
+type Hold struct {
+	ctfSpawnState
+	*holdMode
+	*handlesPickups
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode       = &Hold{}
+	_ HasTimers  = &Hold{}
+	_ TeamMode   = &Hold{}
+	_ FlagMode   = &Hold{}
+	_ PickupMode = &Hold{}
+)
+
+func NewHold(s Server, keepTeams bool) *Hold {
+	return &Hold{
+		holdMode:       newHoldMode(s, keepTeams),
+		handlesPickups: handlingPickups(s),
+	}
+}
+
+func (m *Hold) NeedsMapInfo() bool {
+	return m.handlesPickups.NeedsMapInfo() || m.holdMode.NeedsMapInfo()
+}
+
+func (m *Hold) HandlePacket(p *Player, message P.Message) bool {
+	switch message.Type() {
+
+	case P.N_INITFLAGS, P.N_TAKEFLAG, P.N_TRYDROPFLAG:
+		return m.holdMode.HandlePacket(p, message)
+
+	case P.N_ITEMLIST, P.N_ITEMPICKUP:
+		return m.handlesPickups.HandlePacket(p, message)
+	default:
+		log.Println("received unrelated packet", message)
+		return false
+	}
+}
+
+func (m *Hold) Pause() {
+	m.holdMode.Pause()
+	m.handlesPickups.Pause()
+}
+
+func (m *Hold) Resume() {
+	m.holdMode.Resume()
+	m.handlesPickups.Resume()
+}
+
+func (m *Hold) SetSpeed(speed int32) {
+	m.holdMode.SetSpeed(speed)
+	m.handlesPickups.SetSpeed(speed)
+}
+
+func (m *Hold) CleanUp() {
+	m.holdMode.CleanUp()
+	m.handlesPickups.CleanUp()
+}
+
+func (*Hold) ID() gamemode.ID { return gamemode.Hold }
+
+type EfficHold struct {
+	efficSpawnState
+	*holdMode
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode      = &EfficHold{}
+	_ HasTimers = &EfficHold{}
+	_ TeamMode  = &EfficHold{}
+	_ FlagMode  = &EfficHold{}
+)
+
+func NewEfficHold(s Server, keepTeams bool) *EfficHold {
+	return &EfficHold{
+		holdMode: newHoldMode(s, keepTeams),
+	}
+}
+
+func (*EfficHold) ID() gamemode.ID { return gamemode.EfficHold }
+
+type InstaHold struct {
+	instaSpawnState
+	*holdMode
+}
+
+// assert interface implementations at compile time
+var (
+	_ Mode      = &InstaHold{}
+	_ HasTimers = &InstaHold{}
+	_ TeamMode  = &InstaHold{}
+	_ FlagMode  = &InstaHold{}
+)
+
+func NewInstaHold(s Server, keepTeams bool) *InstaHold {
+	return &InstaHold{
+		holdMode: newHoldMode(s, keepTeams),
+	}
+}
+
+func (*InstaHold) ID() gamemode.ID { return gamemode.InstaHold }
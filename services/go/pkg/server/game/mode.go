@@ -5,6 +5,7 @@ import (
 
 	P "github.com/cfoust/sour/pkg/game/protocol"
 	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/timer"
 )
 
 type Mode interface {
@@ -46,8 +47,10 @@ func (m *teamlessMode) HandleFrag(actor, victim *Player) {
 	victim.Die()
 	if actor == victim {
 		actor.Frags--
+		actor.Stats.Suicides++
 	} else {
 		actor.Frags++
+		actor.Stats.frag()
 	}
 	m.s.Broadcast(P.Died{
 		Client:      int32(victim.CN),
@@ -62,16 +65,27 @@ func (m *teamlessMode) Leave(*Player) {}
 type HasTimers interface {
 	Pause()
 	Resume()
+	SetSpeed(int32)
 	Leave(*Player)
 	CleanUp()
 }
 
+// returns a timer calling f after d has passed in game time, i.e. taking the
+// server's game speed into account
+func gameTimer(s Server, d time.Duration, f func()) *timer.Timer {
+	t := timer.AfterFunc(d, f)
+	t.SetSpeed(s.GameSpeed())
+	return t
+}
+
 type noTimers struct{}
 
 func (*noTimers) Pause() {}
 
 func (*noTimers) Resume() {}
 
+func (*noTimers) SetSpeed(int32) {}
+
 func (*noTimers) Leave(*Player) {}
 
 func (*noTimers) CleanUp() {}
@@ -21,10 +21,24 @@ type noMapInfo struct{}
 
 func (*noMapInfo) NeedsMapInfo() bool { return false }
 
+// powerups are announced this long before they spawn
+const announcePowerupTime = 10 * time.Second
+
 type timedPickup struct {
 	id int32
 	entity.Pickup
-	pendingSpawn *timer.Timer
+	pendingSpawn    *timer.Timer
+	pendingAnnounce *timer.Timer
+}
+
+func (p *timedPickup) timers() []*timer.Timer {
+	timers := []*timer.Timer{}
+	for _, t := range []*timer.Timer{p.pendingSpawn, p.pendingAnnounce} {
+		if t != nil {
+			timers = append(timers, t)
+		}
+	}
+	return timers
 }
 
 type handlesPickups struct {
@@ -75,12 +89,26 @@ func (m *handlesPickups) spawnDelayed(p *timedPickup) {
 	default:
 		panic(fmt.Sprintf("unhandled entity type %d pickup.delay", p.Typ))
 	}
-	p.pendingSpawn = timer.AfterFunc(delay*time.Second, func() {
+	delay *= time.Second
+	if respawn, ok := m.s.PickupRespawn(p.Typ); ok {
+		delay = respawn
+	}
+	p.pendingSpawn = gameTimer(m.s, delay, func() {
 		m.s.Broadcast(P.ItemSpawn{
 			Index: p.id,
 		})
 	})
 	go p.pendingSpawn.Start()
+
+	p.pendingAnnounce = nil
+	if (p.Typ == entity.PickupQuadDamage || p.Typ == entity.PickupBoost) && delay > announcePowerupTime {
+		p.pendingAnnounce = gameTimer(m.s, delay-announcePowerupTime, func() {
+			m.s.Broadcast(P.Announce{
+				Announcement: int32(p.Typ),
+			})
+		})
+		go p.pendingAnnounce.Start()
+	}
 }
 
 func (m *handlesPickups) NeedsMapInfo() bool {
@@ -123,6 +151,9 @@ func (m *handlesPickups) HandlePacket(p *Player, message P.Message) bool {
 		m.spawnDelayed(pu)
 		m.s.Broadcast(P.ItemAck{entityID, int32(p.CN)})
 		p.Pickup(pu)
+		if pu.Typ == entity.PickupQuadDamage {
+			p.QuadTimer.SetSpeed(m.s.GameSpeed())
+		}
 
 	default:
 		log.Println("received unrelated packet", message.Type())
@@ -173,20 +204,47 @@ func (m *handlesPickups) PickupsInitPacket() P.Message {
 
 func (m *handlesPickups) Pause() {
 	for _, p := range m.pickups {
-		p.pendingSpawn.Pause()
+		for _, t := range p.timers() {
+			t.Pause()
+		}
 	}
+	m.s.ForEachPlayer(func(p *Player) {
+		if p.HasQuad() {
+			p.QuadTimer.Pause()
+		}
+	})
 }
 
 func (m *handlesPickups) Resume() {
 	for _, p := range m.pickups {
-		p.pendingSpawn.Start()
+		for _, t := range p.timers() {
+			t.Start()
+		}
 	}
+	m.s.ForEachPlayer(func(p *Player) {
+		if p.QuadTimer != nil {
+			p.QuadTimer.Start()
+		}
+	})
+}
+
+func (m *handlesPickups) SetSpeed(speed int32) {
+	for _, p := range m.pickups {
+		for _, t := range p.timers() {
+			t.SetSpeed(speed)
+		}
+	}
+	m.s.ForEachPlayer(func(p *Player) {
+		if p.QuadTimer != nil {
+			p.QuadTimer.SetSpeed(speed)
+		}
+	})
 }
 
 func (m *handlesPickups) CleanUp() {
 	for id, p := range m.pickups {
-		if p.pendingSpawn != nil {
-			p.pendingSpawn.Stop()
+		for _, t := range p.timers() {
+			t.Stop()
 		}
 		delete(m.pickups, id)
 	}
@@ -12,6 +12,8 @@ type Player struct {
 	Model    int32
 	Position *geom.Vector
 	PlayerState
+	// the weapon the player was last damaged with
+	DamagedBy weapon.ID
 }
 
 func NewPlayer(cn uint32) Player {
@@ -24,6 +26,7 @@ func NewPlayer(cn uint32) Player {
 
 func (p *Player) ApplyDamage(attacker *Player, damage int32, weapon weapon.ID, direction *geom.Vector) {
 	p.PlayerState.applyDamage(damage)
+	p.DamagedBy = weapon
 	if attacker != p && attacker.Team != p.Team {
 		attacker.Damage += damage
 	}
@@ -22,6 +22,7 @@ type PlayerState struct {
 	QuadTimer        *timer.Timer
 	LastShot         time.Time
 	GunReloadEnd     time.Time
+	Projectiles      map[int32]*Projectile // projectile ID → rocket or grenade in flight
 	// reset at spawn to value depending on mode
 	Health         int32
 	Armour         int32
@@ -39,6 +40,7 @@ type PlayerState struct {
 	DamagePotential int32
 	Damage          int32
 	Flags           int32
+	Stats           Stats
 }
 
 func NewPlayerState() PlayerState {
@@ -73,6 +75,7 @@ func (ps *PlayerState) Spawn() {
 	ps.QuadTimer = nil
 	ps.LastShot = time.Time{}
 	ps.GunReloadEnd = time.Time{}
+	ps.ClearProjectiles()
 }
 
 func (ps *PlayerState) SelectWeapon(id weapon.ID) (weapon.Weapon, bool) {
@@ -120,6 +123,7 @@ func (ps *PlayerState) Pickup(p *timedPickup) {
 		}
 		return b
 	}
+	ps.Stats.Pickups++
 	switch p.Typ {
 	case entity.PickupBoost:
 		ps.MaxHealth = min(ps.MaxHealth+p.Amount, p.MaxAmount) // add 50 to max health
@@ -133,18 +137,32 @@ func (ps *PlayerState) Pickup(p *timedPickup) {
 		ps.ArmourType = armour.Yellow
 		ps.Armour = min(ps.Armour+p.Amount, p.MaxAmount)
 	case entity.PickupQuadDamage:
-		timeLeft := ps.QuadTimer.TimeLeft()
-		newTimeLeft := time.Duration(min(int32(timeLeft)+p.Amount, p.MaxAmount))
+		// amounts are in milliseconds
+		timeLeft := int32(ps.QuadTimer.TimeLeft() / time.Millisecond)
+		newTimeLeft := time.Duration(min(timeLeft+p.Amount, p.MaxAmount)) * time.Millisecond
 		if ps.QuadTimer != nil {
 			ps.QuadTimer.Stop()
 		}
 		ps.QuadTimer = timer.NewTimer(newTimeLeft)
-		go ps.QuadTimer.Start()
+		ps.QuadTimer.Start()
 	default:
 		ps.Ammo[weapon.ID(p.Typ-7)] = min(ps.Ammo[weapon.ID(p.Typ-7)]+p.Amount, p.MaxAmount)
 	}
 }
 
+// HasQuad returns true while the player's quad damage is active.
+func (ps *PlayerState) HasQuad() bool {
+	return ps.QuadTimer.TimeLeft() > 0
+}
+
+// Returns the damage the player deals with the given base damage.
+func (ps *PlayerState) ScaleDamage(damage int32) int32 {
+	if ps.HasQuad() {
+		return damage * weapon.QuadDamageScale
+	}
+	return damage
+}
+
 func (ps *PlayerState) Die() {
 	if ps.State != playerstate.Alive {
 		return
@@ -152,9 +170,12 @@ func (ps *PlayerState) Die() {
 	ps.State = playerstate.Dead
 	ps.Deaths++
 	ps.LastDeath = time.Now()
+	ps.Stats.Streak = 0
 	if ps.QuadTimer != nil {
 		ps.QuadTimer.Stop()
 	}
+	// projectiles can't hurt anyone once their owner is dead
+	ps.ClearProjectiles()
 }
 
 // Resets a client's game state.
@@ -165,6 +186,7 @@ func (ps *PlayerState) Reset() {
 
 	ps.LifeSequence = 0
 	ps.LastDeath = time.Time{}
+	ps.ClearProjectiles()
 	ps.MaxHealth = 100
 	ps.Frags = 0
 	ps.Deaths = 0
@@ -172,6 +194,7 @@ func (ps *PlayerState) Reset() {
 	ps.DamagePotential = 0
 	ps.Damage = 0
 	ps.Flags = 0
+	ps.Stats.reset()
 }
 
 // below are Spawn methods scoped on empty structs for embedding into game modes
@@ -220,3 +243,12 @@ func (*ctfSpawnState) Spawn(ps *PlayerState) {
 	ps.Ammo, ps.SelectedWeapon = weapon.SpawnAmmoFFA()
 	ps.Health = ps.MaxHealth
 }
+
+type captureSpawnState struct{}
+
+func (*captureSpawnState) Spawn(ps *PlayerState) {
+	ps.ArmourType = armour.Blue
+	ps.Armour = 25
+	ps.Ammo, ps.SelectedWeapon = weapon.SpawnAmmoCapture()
+	ps.Health = ps.MaxHealth
+}
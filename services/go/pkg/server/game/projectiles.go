@@ -0,0 +1,99 @@
+package game
+
+import (
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+)
+
+const (
+	// the most projectiles a player can have in flight at once
+	maxProjectiles = 8
+	// grenades explode once their time to live ran out, but clients only
+	// check that once per frame
+	grenadeFlightTolerance = 500 * time.Millisecond
+	// rockets fly until they hit something, this is enough to cross the
+	// biggest maps
+	maxRocketFlightTime = 30 * time.Second
+	// the shot and the explosion can take different amounts of time to reach
+	// us, so projectiles can seem to fly a bit longer than they did
+	maxProjectileLatency = 500 * time.Millisecond
+)
+
+// A Projectile is a rocket or grenade that was fired and did not explode yet.
+type Projectile struct {
+	// the ID the client assigned, which is the client's game time (in
+	// milliseconds since the map started) at the time of the shot
+	ID     int32
+	Weapon weapon.ID
+	Fired  time.Time
+	Origin *geom.Vector
+}
+
+// Returns the longest time (in game time) the projectile can fly before
+// exploding.
+func (p *Projectile) maxFlightTime() time.Duration {
+	if p.Weapon == weapon.GrenadeLauncher {
+		return time.Duration(weapon.ByID(p.Weapon).TimeToLive)*time.Millisecond + grenadeFlightTolerance
+	}
+	return maxRocketFlightTime
+}
+
+// Plausible returns true if the projectile can explode now, going by when we
+// learned that it was fired and the game speed. The time the game was paused
+// counts as flight time.
+func (p *Projectile) Plausible(speed int32) bool {
+	flightTime := (time.Since(p.Fired) - maxProjectileLatency) * time.Duration(speed) / 100
+	return flightTime <= p.maxFlightTime()
+}
+
+func isProjectileWeapon(id weapon.ID) bool {
+	return id == weapon.RocketLauncher || id == weapon.GrenadeLauncher
+}
+
+// AddProjectile keeps track of a projectile the player fired, if the weapon
+// fires projectiles. If the player has too many projectiles in flight, the
+// oldest one is dropped.
+func (ps *PlayerState) AddProjectile(id int32, wpn weapon.ID, origin *geom.Vector) {
+	if !isProjectileWeapon(wpn) {
+		return
+	}
+
+	if ps.Projectiles == nil {
+		ps.Projectiles = map[int32]*Projectile{}
+	}
+
+	if len(ps.Projectiles) >= maxProjectiles {
+		var oldest *Projectile
+		for _, p := range ps.Projectiles {
+			if oldest == nil || p.Fired.Before(oldest.Fired) {
+				oldest = p
+			}
+		}
+		delete(ps.Projectiles, oldest.ID)
+	}
+
+	ps.Projectiles[id] = &Projectile{
+		ID:     id,
+		Weapon: wpn,
+		Fired:  time.Now(),
+		Origin: origin,
+	}
+}
+
+// TakeProjectile removes the projectile with the given ID and weapon and
+// returns it, or returns false if the player has no such projectile in
+// flight.
+func (ps *PlayerState) TakeProjectile(id int32, wpn weapon.ID) (*Projectile, bool) {
+	p, ok := ps.Projectiles[id]
+	if !ok || p.Weapon != wpn {
+		return nil, false
+	}
+	delete(ps.Projectiles, id)
+	return p, true
+}
+
+func (ps *PlayerState) ClearProjectiles() {
+	ps.Projectiles = nil
+}
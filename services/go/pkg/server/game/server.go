@@ -4,14 +4,20 @@ import (
 	"time"
 
 	"github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/entity"
 )
 
 type Server interface {
 	GameDuration() time.Duration
+	GameSpeed() int32 // in percent of real time
 	Broadcast(messages ...protocol.Message)
 	Message(message string)
 	Intermission()
 	ForEachPlayer(func(*Player))
 	UniqueName(*Player) string
 	NumberOfPlayers() int
+	// how long items of the given type take to respawn, if not the default
+	PickupRespawn(entity.ID) (time.Duration, bool)
+	// gives a living player what the mode spawns them with right away
+	Rearm(*Player)
 }
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+)
+
+type WeaponStats struct {
+	Shots           int32
+	Hits            int32
+	Damage          int32
+	DamagePotential int32
+}
+
+// Stats keep track of how a player did in the current match, beyond what the
+// game itself needs to know.
+type Stats struct {
+	Weapons        map[weapon.ID]*WeaponStats
+	DamageReceived int32
+	Suicides       int32
+	Pickups        int32
+	FlagsTaken     int32
+	FlagsReturned  int32
+
+	// frags since the player last died
+	Streak        int32
+	LongestStreak int32
+}
+
+func (s *Stats) reset() {
+	*s = Stats{
+		Weapons: map[weapon.ID]*WeaponStats{},
+	}
+}
+
+func (s *Stats) weapon(id weapon.ID) *WeaponStats {
+	if s.Weapons == nil {
+		s.Weapons = map[weapon.ID]*WeaponStats{}
+	}
+	stats, ok := s.Weapons[id]
+	if !ok {
+		stats = &WeaponStats{}
+		s.Weapons[id] = stats
+	}
+	return stats
+}
+
+// Shot records a shot that could have done up to potential damage.
+func (s *Stats) Shot(id weapon.ID, potential int32) {
+	stats := s.weapon(id)
+	stats.Shots++
+	stats.DamagePotential += potential
+}
+
+// Hit records damage done to another player.
+func (s *Stats) Hit(id weapon.ID, damage int32) {
+	stats := s.weapon(id)
+	stats.Hits++
+	stats.Damage += damage
+}
+
+func (s *Stats) frag() {
+	s.Streak++
+	if s.Streak > s.LongestStreak {
+		s.LongestStreak = s.Streak
+	}
+}
@@ -71,10 +71,16 @@ func (*teamMode) Leave(p *Player) {
 
 func (m *teamMode) HandleFrag(fragger, victim *Player) {
 	victim.Die()
-	if fragger.Team == victim.Team {
+	switch {
+	case fragger == victim:
 		fragger.Frags--
-	} else {
+		fragger.Stats.Suicides++
+	case fragger.Team == victim.Team:
+		fragger.Frags--
+		fragger.Teamkills++
+	default:
 		fragger.Frags++
+		fragger.Stats.frag()
 	}
 	m.s.Broadcast(P.Died{int32(victim.CN), int32(fragger.CN), fragger.Frags, fragger.Team.Frags})
 }
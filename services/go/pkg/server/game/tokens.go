@@ -0,0 +1,467 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/timer"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// values taken from collect.h in the vanilla game
+const (
+	tokenBaseRadius   = 16
+	tokenBaseHeight   = 16
+	maxTokenBases     = 20
+	tokenRadius       = 16
+	tokenLimit        = 5  // skulls a single player can carry
+	unownedTokenLimit = 15 // skulls lying around before the oldest expire
+	collectScoreLimit = 50
+	expireTokenTime   = 10 * time.Second
+	stealTokenTime    = 5 * time.Second
+
+	// positions reported by clients lag behind a bit, so we allow some
+	// extra distance when checking whether a player touched something
+	touchTolerance = 16
+)
+
+type CollectMode interface {
+	NeedsMapInfo() bool
+	InitBases([]P.ClientTokenBase)
+	TokensInitPacket() P.Message
+}
+
+type tokenBase struct {
+	index     int32
+	team      *Team
+	teamID    int32
+	position  *geom.Vector
+	lastSteal time.Time
+}
+
+type token struct {
+	id       int32
+	team     *Team
+	teamID   int32
+	yaw      int32
+	position *geom.Vector
+	// time until the token expires, only counts down while the game is running
+	timeLeft time.Duration
+}
+
+type handlesTokens struct {
+	*teamMode
+	s          Server
+	good       *Team
+	evil       *Team
+	mutex      deadlock.Mutex
+	bases      []*tokenBase
+	tokens     []*token
+	nextToken  int32
+	carried    map[*Player]int32
+	nextUpdate *timer.Timer
+	cleanedUp  bool
+}
+
+var (
+	_ CollectMode    = &handlesTokens{}
+	_ HasTimers      = &handlesTokens{}
+	_ TeamMode       = &handlesTokens{}
+	_ HandlesPackets = &handlesTokens{}
+)
+
+func handlingTokens(s Server, keepTeams bool) *handlesTokens {
+	good, evil := NewTeam("good"), NewTeam("evil")
+	m := &handlesTokens{
+		teamMode: withTeams(s, false, keepTeams, good, evil),
+		s:        s,
+		good:     good,
+		evil:     evil,
+		carried:  map[*Player]int32{},
+	}
+	m.scheduleUpdate()
+	return m
+}
+
+func (m *handlesTokens) teamByID(id int32) *Team {
+	switch id {
+	case 1:
+		return m.good
+	case 2:
+		return m.evil
+	default:
+		return nil
+	}
+}
+
+func (m *handlesTokens) teamID(t *Team) int32 {
+	switch t {
+	case m.good:
+		return 1
+	case m.evil:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func (m *handlesTokens) enemyOf(t *Team) *Team {
+	switch t {
+	case m.good:
+		return m.evil
+	case m.evil:
+		return m.good
+	default:
+		return nil
+	}
+}
+
+func (m *handlesTokens) scheduleUpdate() {
+	const interval = time.Second
+	m.nextUpdate = gameTimer(m.s, interval, func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.cleanedUp {
+			return
+		}
+		m.expireTokens(interval)
+		m.scheduleUpdate()
+	})
+	m.nextUpdate.Start()
+}
+
+func (m *handlesTokens) NeedsMapInfo() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.bases) == 0
+}
+
+func (m *handlesTokens) HandlePacket(p *Player, message P.Message) bool {
+	switch message.Type() {
+	case P.N_INITTOKENS:
+		if p.State == playerstate.Spectator {
+			break
+		}
+		m.InitBases(message.(P.ClientInitTokens).Bases)
+
+	case P.N_TAKETOKEN:
+		m.takeToken(p, message.(P.ClientTakeToken).Token)
+
+	case P.N_DEPOSITTOKENS:
+		m.depositTokens(p, message.(P.ClientDepositTokens).Base)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (m *handlesTokens) InitBases(bases []P.ClientTokenBase) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.bases) != 0 {
+		return
+	}
+
+	if len(bases) > maxTokenBases {
+		log.Printf("got %d skull bases, only using the first %d", len(bases), maxTokenBases)
+		bases = bases[:maxTokenBases]
+	}
+
+	for i, b := range bases {
+		team := m.teamByID(b.Team)
+		if team == nil {
+			continue
+		}
+		m.bases = append(m.bases, &tokenBase{
+			index:    int32(i),
+			team:     team,
+			teamID:   b.Team,
+			position: geom.NewVector(b.Position.X, b.Position.Y, b.Position.Z),
+		})
+	}
+}
+
+func (m *handlesTokens) TokensInitPacket() P.Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	message := P.InitTokens{}
+	message.TeamScores[0].Score = m.good.Score
+	message.TeamScores[1].Score = m.evil.Score
+
+	for _, t := range m.tokens {
+		message.Tokens = append(message.Tokens, P.TokenState{
+			Token: t.id,
+			Team:  t.teamID,
+			Yaw:   t.yaw,
+			X:     int32(t.position.X() * geom.DMF),
+			Y:     int32(t.position.Y() * geom.DMF),
+			Z:     int32(t.position.Z() * geom.DMF),
+		})
+	}
+
+	for p, n := range m.carried {
+		if p.State == playerstate.Alive && n > 0 {
+			message.ClientTokens = append(message.ClientTokens, P.ClientTokenState{
+				Client: int32(p.CN),
+				Count:  n,
+			})
+		}
+	}
+
+	return message
+}
+
+func (m *handlesTokens) dropToken(team *Team, position *geom.Vector, yaw int32) *token {
+	t := &token{
+		id:       m.nextToken,
+		team:     team,
+		teamID:   m.teamID(team),
+		yaw:      yaw,
+		position: position,
+		timeLeft: expireTokenTime,
+	}
+	m.nextToken++
+	m.tokens = append(m.tokens, t)
+	return t
+}
+
+func (m *handlesTokens) removeToken(id int32) *token {
+	for i, t := range m.tokens {
+		if t.id == id {
+			m.tokens = append(m.tokens[:i], m.tokens[i+1:]...)
+			return t
+		}
+	}
+	return nil
+}
+
+// dropTokens drops the skulls p carried as well as one skull of p's own team,
+// unless p died by their own hand (penalty), in which case only p's skull
+// drops.
+func (m *handlesTokens) dropTokens(p *Player, penalty bool) {
+	if len(m.bases) == 0 || p.Position == nil {
+		delete(m.carried, p)
+		return
+	}
+
+	message := P.DropTokens{
+		Client: int32(p.CN),
+		Dropx:  int32(p.Position.X() * geom.DMF),
+		Dropy:  int32(p.Position.Y() * geom.DMF),
+		Dropz:  int32(p.Position.Z() * geom.DMF),
+	}
+
+	drop := func(team *Team) {
+		t := m.dropToken(team, p.Position, rng.Int31n(360))
+		message.Tokens = append(message.Tokens, P.DroppedToken{
+			Token: t.id,
+			Team:  t.teamID,
+			Yaw:   t.yaw,
+		})
+	}
+
+	if !penalty {
+		for i := int32(0); i < m.carried[p]; i++ {
+			drop(m.enemyOf(p.Team))
+		}
+	}
+	if m.teamID(p.Team) != 0 {
+		drop(p.Team)
+	}
+	delete(m.carried, p)
+
+	m.limitUnownedTokens()
+	m.s.Broadcast(message)
+}
+
+// limitUnownedTokens expires the oldest skulls when too many are lying around.
+func (m *handlesTokens) limitUnownedTokens() {
+	if len(m.tokens) <= unownedTokenLimit {
+		return
+	}
+
+	message := P.ExpireTokens{}
+	for _, t := range m.tokens[:len(m.tokens)-unownedTokenLimit] {
+		message.Tokens = append(message.Tokens, P.ExpiredToken{Token: t.id})
+	}
+	m.tokens = m.tokens[len(m.tokens)-unownedTokenLimit:]
+	m.s.Broadcast(message)
+}
+
+func (m *handlesTokens) expireTokens(elapsed time.Duration) {
+	message := P.ExpireTokens{}
+	remaining := []*token{}
+	for _, t := range m.tokens {
+		t.timeLeft -= elapsed
+		if t.timeLeft <= 0 {
+			message.Tokens = append(message.Tokens, P.ExpiredToken{Token: t.id})
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	m.tokens = remaining
+
+	if len(message.Tokens) > 0 {
+		m.s.Broadcast(message)
+	}
+}
+
+func (m *handlesTokens) takeToken(p *Player, id int32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.bases) == 0 || p.State != playerstate.Alive {
+		return
+	}
+
+	var t *token
+	for _, _t := range m.tokens {
+		if _t.id == id {
+			t = _t
+		}
+	}
+	if t == nil {
+		return
+	}
+	if p.Position != nil && geom.Distance(p.Position, t.position) > tokenRadius+touchTolerance {
+		log.Printf("player %d tried to take skull %d from too far away", p.CN, id)
+		return
+	}
+
+	if t.team != p.Team {
+		if m.carried[p] >= tokenLimit {
+			return
+		}
+		m.carried[p]++
+	}
+	// touching a skull of your own team denies it to the enemy
+
+	m.removeToken(id)
+	m.s.Broadcast(P.TakeToken{
+		Client: int32(p.CN),
+		Token:  id,
+		Total:  m.carried[p],
+	})
+}
+
+func (m *handlesTokens) depositTokens(p *Player, baseIndex int32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if p.State != playerstate.Alive || baseIndex < 0 || int(baseIndex) >= len(m.bases) {
+		return
+	}
+
+	b := m.bases[baseIndex]
+	if b.team == p.Team || m.teamID(p.Team) == 0 {
+		// skulls are scored at the enemy base
+		return
+	}
+	if p.Position != nil {
+		dx, dy, dz := b.position.X()-p.Position.X(), b.position.Y()-p.Position.Y(), b.position.Z()-p.Position.Z()
+		const radius = tokenBaseRadius + touchTolerance
+		if dx*dx+dy*dy > radius*radius || dz < -tokenBaseHeight-touchTolerance || dz > tokenBaseHeight+touchTolerance {
+			log.Printf("player %d tried to use skull base %d from too far away", p.CN, baseIndex)
+			return
+		}
+	}
+
+	if m.carried[p] <= 0 {
+		m.stealTokens(p, b)
+		return
+	}
+
+	deposited := m.carried[p]
+	delete(m.carried, p)
+	p.Flags += deposited
+	p.Team.Score += deposited
+
+	m.s.Broadcast(P.DepositTokens{
+		Client:    int32(p.CN),
+		Base:      b.index,
+		Deposited: deposited,
+		Team:      m.teamID(p.Team),
+		Score:     p.Team.Score,
+		Flags:     p.Flags,
+	})
+
+	if p.Team.Score >= collectScoreLimit {
+		go m.s.Intermission()
+	}
+}
+
+// stealTokens turns a point of the enemy team's score back into a skull lying
+// at their base.
+func (m *handlesTokens) stealTokens(p *Player, b *tokenBase) {
+	if b.team.Score <= 0 || (!b.lastSteal.IsZero() && time.Since(b.lastSteal) < stealTokenTime) {
+		return
+	}
+	b.lastSteal = time.Now()
+	b.team.Score--
+
+	t := m.dropToken(b.team, b.position, rng.Int31n(360))
+	m.limitUnownedTokens()
+
+	m.s.Broadcast(P.StealTokens{
+		Client:    int32(p.CN),
+		Team:      m.teamID(p.Team),
+		Basenum:   b.index,
+		Enemyteam: b.teamID,
+		Score:     b.team.Score,
+		Dropx:     int32(b.position.X() * geom.DMF),
+		Dropy:     int32(b.position.Y() * geom.DMF),
+		Dropz:     int32(b.position.Z() * geom.DMF),
+		Tokens: []P.DroppedToken{{
+			Token: t.id,
+			Team:  t.teamID,
+			Yaw:   t.yaw,
+		}},
+	})
+}
+
+func (m *handlesTokens) HandleFrag(actor, victim *Player) {
+	m.mutex.Lock()
+	m.dropTokens(victim, actor == victim || actor.Team == victim.Team)
+	m.mutex.Unlock()
+	m.teamMode.HandleFrag(actor, victim)
+}
+
+func (m *handlesTokens) Leave(p *Player) {
+	m.mutex.Lock()
+	delete(m.carried, p)
+	m.mutex.Unlock()
+	m.teamMode.Leave(p)
+}
+
+func (m *handlesTokens) Pause() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.Pause()
+}
+
+func (m *handlesTokens) Resume() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.Start()
+}
+
+func (m *handlesTokens) SetSpeed(speed int32) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextUpdate.SetSpeed(speed)
+}
+
+func (m *handlesTokens) CleanUp() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.cleanedUp = true
+	m.nextUpdate.Stop()
+}
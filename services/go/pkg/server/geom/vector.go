@@ -29,6 +29,14 @@ func (v *Vector) Sub(o *Vector) *Vector {
 	return NewVector(v.x-o.x, v.y-o.y, v.z-o.z)
 }
 
+func (v *Vector) Add(o *Vector) *Vector {
+	return NewVector(v.x+o.x, v.y+o.y, v.z+o.z)
+}
+
+func (v *Vector) Dot(o *Vector) float64 {
+	return v.x*o.x + v.y*o.y + v.z*o.z
+}
+
 func (v *Vector) Mul(k float64) *Vector {
 	return NewVector(v.x*k, v.y*k, v.z*k)
 }
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Clients decide for themselves whom their hitscan weapons hit. To keep them
+// honest, we remember where every player was over the last few seconds and
+// check each reported hit against where the target was when the shooter saw
+// them, which is about one round trip ago.
+
+const (
+	// about two seconds of position updates
+	positionHistorySize = 64
+	// round trips longer than this don't buy a player any more leeway
+	maxLagCompensation = time.Second
+	// how much further back than the shooter's round trip we look, for
+	// jitter and the smoothing of other players' movement in the client
+	lagWindow = 200 * time.Millisecond
+
+	// the size of a player, see physent in the game
+	playerEyeHeight = 14.0
+	playerAboveEye  = 1.0
+	// how far a shot may miss the middle of its target, on top of half the
+	// height of the player, because of movement between updates and rounding
+	hitTolerance = 16.0
+	// how far from the shooter's last known position a shot may start
+	maxShotOriginDistance = 48.0
+)
+
+type positionSample struct {
+	time         time.Time
+	lifeSequence int32
+	position     *geom.Vector
+}
+
+// A ring buffer of a player's recent positions.
+type positionHistory struct {
+	samples [positionHistorySize]positionSample
+	next    int
+}
+
+func (h *positionHistory) add(lifeSequence int32, position *geom.Vector) {
+	h.samples[h.next] = positionSample{
+		time:         time.Now(),
+		lifeSequence: lifeSequence,
+		position:     position,
+	}
+	h.next = (h.next + 1) % positionHistorySize
+}
+
+// Returns the positions the player had during the given life since the given
+// time, including the one they had at that time.
+func (h *positionHistory) since(t time.Time, lifeSequence int32) []*geom.Vector {
+	positions := []*geom.Vector{}
+	for i := 1; i <= positionHistorySize; i++ {
+		sample := h.samples[(h.next-i+positionHistorySize)%positionHistorySize]
+		if sample.position == nil || sample.lifeSequence != lifeSequence {
+			break
+		}
+		positions = append(positions, sample.position)
+		if sample.time.Before(t) {
+			break
+		}
+	}
+	return positions
+}
+
+// Whether a shot of wpn from the given point could have hit something at
+// position (the target's eyes).
+func canHit(wpn weapon.Weapon, from, to, position *geom.Vector) bool {
+	center := geom.NewVector(
+		position.X(),
+		position.Y(),
+		position.Z()-(playerEyeHeight-playerAboveEye)/2,
+	)
+	if geom.Distance(from, center) > wpn.Range+hitTolerance {
+		return false
+	}
+
+	shot := to.Sub(from)
+	length := shot.Magnitude()
+	dir := shot.Scale(1)
+
+	// how far along the shot the target is: it can't be behind the shooter
+	// or behind whatever stopped the shot
+	along := center.Sub(from).Dot(dir)
+	if along < -hitTolerance || along > length+hitTolerance {
+		return false
+	}
+
+	// rays of spread out weapons diverge from the aim by up to
+	// spread/2048 units per unit travelled, see offsetray() in the game
+	allowed := (playerEyeHeight+playerAboveEye)/2 + hitTolerance + along*float64(wpn.Spread)/2048
+	closest := from.Add(dir.Mul(along))
+	return geom.Distance(center, closest) <= allowed
+}
+
+// Clients answer every N_PONG with the ping they measured in N_CLIENTPING, so
+// the time in between is a round trip the client can't lie about (other than
+// by answering late, which lag compensation caps).
+func (c *Client) measureLatency() {
+	if c.pongSent.IsZero() {
+		return
+	}
+	roundTrip := time.Since(c.pongSent)
+	c.pongSent = time.Time{}
+
+	// smoothed the same way clients smooth their ping
+	if c.latency == 0 {
+		c.latency = roundTrip
+	} else {
+		c.latency = (c.latency*5 + roundTrip) / 6
+	}
+}
+
+// Checks whether the shot client fired could have hit target, going by where
+// target was when client pulled the trigger.
+func (s *Server) validHit(client, target *Client, wpn weapon.Weapon, from, to *geom.Vector) bool {
+	// where the shooter says the shot came from has to match where they are
+	if client.Position != nil && geom.Distance(client.Position, from) > maxShotOriginDistance {
+		log.Debug().Msgf("%s shot from %v, but is at %v", client, from, client.Position)
+		return false
+	}
+
+	lag := client.latency
+	if lag > maxLagCompensation {
+		lag = maxLagCompensation
+	}
+
+	positions := target.history.since(time.Now().Add(-lag-lagWindow), target.LifeSequence)
+	// we can't tell where a target that didn't move yet is, so we can't
+	// tell whether it was hit either
+	if len(positions) == 0 {
+		log.Debug().Msgf("%s hit %s, who has not moved yet", client, target)
+		return false
+	}
+
+	for _, position := range positions {
+		if canHit(wpn, from, to, position) {
+			return true
+		}
+	}
+
+	log.Debug().Msgf("%s hit %s, who was not in the line of fire", client, target)
+	return false
+}
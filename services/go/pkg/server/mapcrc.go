@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+)
+
+// Clients report the CRC of the map they loaded with N_MAPCRC. Whoever provides
+// the server with maps tells us the CRC of the real map with SetMapCRC, so we
+// can catch players that play with modified geometry.
+
+// Forgets the CRC of the previous map and what clients reported for it.
+func (s *Server) resetMapCRC() {
+	s.mapCRC = 0
+
+	s.Clients.ForEach(func(c *Client) {
+		c.MapCRC = 0
+		c.ModifiedMap = false
+	})
+}
+
+type mapCRC struct {
+	mapName string
+	crc     int32
+}
+
+// SetMapCRC sets the CRC of the uncompressed data of the given map. It is
+// ignored if the server has moved on to another map in the meantime.
+func (s *Server) SetMapCRC(mapName string, crc int32) {
+	select {
+	case s.mapCRCs <- mapCRC{mapName, crc}:
+	case <-s.Ctx().Done():
+	}
+}
+
+func (s *Server) applyMapCRC(m mapCRC) {
+	if m.mapName != s.Map {
+		return
+	}
+	s.mapCRC = m.crc
+
+	// check the clients that were faster than us
+	s.Clients.ForEach(s.checkMapCRC)
+}
+
+func (s *Server) HandleMapCRC(c *Client, mapName string, crc int32) {
+	if mapName != s.Map {
+		return
+	}
+
+	c.MapCRC = crc
+	s.checkMapCRC(c)
+}
+
+func (s *Server) checkMapCRC(c *Client) {
+	expected := s.mapCRC
+
+	// 0 means either we or the client don't have the map (yet)
+	if expected == 0 || c.MapCRC == 0 || c.ModifiedMap || c.IsBot() {
+		return
+	}
+	// maps change all the time while editing
+	if s.GameMode.ID() == gamemode.CoopEdit {
+		return
+	}
+	if c.MapCRC == expected {
+		return
+	}
+
+	c.ModifiedMap = true
+	s.Message(cubecode.Fail(fmt.Sprintf("%s has modified map \"%s\"", s.Clients.UniqueName(c), s.Map)))
+
+	if s.CompetitiveMode && c.State != playerstate.Spectator {
+		s.SetSpectator(c, true)
+		c.Message("you were moved to spectators because your map differs from the server's")
+	}
+}
+
+// CheckMaps tells c which players have a modified map or did not report
+// their map yet, like /checkmaps in vanilla.
+func (s *Server) CheckMaps(c *Client) {
+	if s.mapCRC == 0 {
+		c.Message("the server does not know the map yet")
+		return
+	}
+
+	numModified := 0
+	s.Clients.ForEach(func(other *Client) {
+		if other.IsBot() {
+			return
+		}
+		switch {
+		case other.ModifiedMap:
+			c.Message(fmt.Sprintf("%s has modified map \"%s\"", s.Clients.UniqueName(other), s.Map))
+			numModified++
+		case other.MapCRC == 0:
+			c.Message(fmt.Sprintf("%s does not have map \"%s\" loaded", s.Clients.UniqueName(other), s.Map))
+		}
+	})
+
+	if numModified == 0 {
+		c.Message("no one has a modified map")
+	}
+}
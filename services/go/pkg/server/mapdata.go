@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// In coop edit, players can upload the map they are working on with /sendmap.
+// We keep the latest one around for anyone who joins later and asks for it
+// with /getmap, like the vanilla server.
+
+// value taken from server.cpp in the vanilla game
+const maxMapDataSize = 4 * 1024 * 1024
+
+// Handles a map a client uploaded on channel 2.
+func (s *Server) ReceiveMapData(c *Client, data []byte) {
+	if s.GameMode.ID() != gamemode.CoopEdit || len(data) == 0 || len(data) > maxMapDataSize {
+		return
+	}
+	if c.State == playerstate.Spectator && c.Role == role.None {
+		return
+	}
+
+	s.mapData = data
+	s.Message(fmt.Sprintf("[%s sent a map to server, \"/getmap\" to receive it]", s.Clients.UniqueName(c)))
+}
+
+// Sends the map that was last uploaded to c.
+func (s *Server) SendMapData(c *Client) {
+	if s.mapData == nil {
+		c.Message(cubecode.Fail("no map to send"))
+		return
+	}
+
+	s.Message(fmt.Sprintf("[%s is getting the map]", s.Clients.UniqueName(c)))
+	c.sendOnChannel(2, P.SendMap{Map: s.mapData})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	C "github.com/cfoust/sour/pkg/game/constants"
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/geom"
+)
+
+// MapEntity is an entity of a map, as far as bots care about it.
+type MapEntity struct {
+	// the position of the entity in the map's list of entities, which is
+	// how clients refer to it
+	Index    int32
+	Type     C.EntityType
+	Position *geom.Vector
+	Attr1    int16
+	Attr2    int16
+}
+
+// MapInfo is what whoever provides the server with maps learned from reading
+// one of them.
+type MapInfo struct {
+	Map string
+
+	// the bases of capture modes
+	Bases []P.ClientBaseState
+	// the skull bases of collect modes
+	TokenBases []P.ClientTokenBase
+	// the player starts, pickups and teleports bots find their way with
+	Entities []MapEntity
+}
+
+// SetMapInfo hands the server what is known about the given map. Reading maps
+// takes a while, so it is ignored if the server has moved on to another map
+// in the meantime.
+func (s *Server) SetMapInfo(info MapInfo) {
+	select {
+	case s.mapInfos <- info:
+	case <-s.Ctx().Done():
+	}
+}
+
+func (s *Server) applyMapInfo(info MapInfo) {
+	if info.Map != s.Map {
+		return
+	}
+
+	s.nav.setEntities(info.Entities)
+
+	// capture and collect modes can set up their bases without waiting for
+	// a client to send them
+	if captureMode, ok := s.GameMode.(game.CaptureMode); ok && captureMode.NeedsMapInfo() && len(info.Bases) > 0 {
+		captureMode.InitBases(info.Bases)
+	}
+	if collectMode, ok := s.GameMode.(game.CollectMode); ok && collectMode.NeedsMapInfo() && len(info.TokenBases) > 0 {
+		collectMode.InitBases(info.TokenBases)
+	}
+}
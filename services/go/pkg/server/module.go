@@ -23,6 +23,7 @@ import (
 	"github.com/cfoust/sour/pkg/utils"
 
 	"github.com/rs/zerolog/log"
+	"github.com/sasha-s/go-deadlock"
 )
 
 type ServerPacket struct {
@@ -53,20 +54,89 @@ type Server struct {
 
 	Commands *commands.CommandGroup[*Client]
 
-	pendingMapChange *time.Timer
+	pendingMapChange *mapChange
+	mapChanges       chan *mapChange
 	rng              *rand.Rand
 
 	incoming chan ServerPacket
 	outgoing chan ServerPacket
 	maps     chan string
 
+	// the CRC of the current map, 0 if unknown
+	mapCRC int32
+	// the CRCs of the maps the server switched to
+	mapCRCs chan mapCRC
+
+	// the map last uploaded in coop edit
+	mapData []byte
+
+	// the vote for the next map, if one is going on
+	vote *mapVote
+	// votes whose window closed
+	voteTimeouts chan *mapVote
+
+	// what was read from the maps the server switched to
+	mapInfos chan MapInfo
+
+	// how bots find their way around the current map
+	nav *navigation
+
+	// the entry of the map rotation after the one played last
+	rotationIndex int
+	recentMaps    []string
+
 	Broadcasts *utils.Topic[[]P.Message]
 	Edits      *utils.Topic[MapEdit]
 
+	// the match being recorded, which Intermission and StartGame change
+	// from different goroutines
+	demo      *demoRecorder
+	demoMutex deadlock.Mutex
+	// demos of the last few matches
+	demos         []*Demo
+	demosMutex    deadlock.Mutex
+	FinishedDemos *utils.Topic[*Demo]
+
+	// players caught moving in impossible ways
+	Cheats *utils.Topic[Cheat]
+
+	// clients kicked from the server, and requests to lift the bans that
+	// resulted from kicks
+	Kicks       *utils.Topic[Kick]
+	BansCleared *utils.Topic[struct{}]
+
+	// stats of the current match
+	matchStarted time.Time
+	departed     []PlayerReport
+	MatchReports *utils.Topic[*MatchReport]
+
+	// looks up auth keys that aren't in the config, like those of users
+	// registered with the cluster
+	FindAuthKey func(domain, name string) *AuthKey
+
+	// looks up the rating of a player, if the owner of the server knows it
+	Rating func(sessionID uint32) (int, bool)
+
+	// looks up the ID of the account a player is logged in with, if the
+	// owner of the server knows it
+	Account func(sessionID uint32) (uint, bool)
+
+	// what players need to know to join, if anything; the server only tells
+	// clients about it, checking it is up to whoever lets players in
+	Password string
+
 	// non-standard stuff
 	KeepTeams       bool
 	CompetitiveMode bool
 	ReportStats     bool
+	TeamsLocked     bool
+	ClanWar         bool
+
+	// the clan war being played, if any
+	war *clanWar
+
+	// changes to the stock weapons and items
+	rules *rules
 }
 
 func New(ctx context.Context, conf *Config) *Server {
@@ -80,15 +150,23 @@ func New(ctx context.Context, conf *Config) *Server {
 	outgoing := make(chan ServerPacket)
 
 	s := &Server{
-		Session:    utils.NewSession(ctx),
-		Broadcasts: broadcasts,
-		Commands:   commands.NewCommandGroup[*Client]("server", G.ColorBlue),
-		Edits:      utils.NewTopic[MapEdit](),
-		Config:     conf,
+		Session:       utils.NewSession(ctx),
+		Broadcasts:    broadcasts,
+		Commands:      commands.NewCommandGroup[*Client]("server", G.ColorBlue),
+		Edits:         utils.NewTopic[MapEdit](),
+		FinishedDemos: utils.NewTopic[*Demo](),
+		Cheats:        utils.NewTopic[Cheat](),
+		Kicks:         utils.NewTopic[Kick](),
+		BansCleared:   utils.NewTopic[struct{}](),
+		MatchReports:  utils.NewTopic[*MatchReport](),
+		Config:        conf,
 		State: &State{
-			MasterMode: mastermode.Auth,
-			UpSince:    time.Now(),
-			NumClients: clients.GetNumClients,
+			MasterMode:  mastermode.Auth,
+			UpSince:     time.Now(),
+			NumClients:  clients.GetNumClients,
+			BotLimit:    defaultBotLimit,
+			RecordDemos: true,
+			Speed:       defaultGameSpeed,
 		},
 		relay:    relay.New(),
 		Clients:  clients,
@@ -96,6 +174,22 @@ func New(ctx context.Context, conf *Config) *Server {
 		outgoing: outgoing,
 		maps:     make(chan string, 1),
 		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		rules: parseRules(conf),
+
+		voteTimeouts: make(chan *mapVote, 1),
+		mapChanges:   make(chan *mapChange, 1),
+		mapInfos:     make(chan MapInfo),
+		mapCRCs:      make(chan mapCRC),
+		nav:          newNavigation(),
+
+		ReportStats: true,
+	}
+
+	s.registerCommands()
+
+	if conf.DefaultGameSpeed != 0 {
+		s.Speed = clampGameSpeed(int32(conf.DefaultGameSpeed))
 	}
 
 	return s
@@ -105,12 +199,35 @@ func (s *Server) Poll(ctx context.Context) {
 	chanLock := chanlock.New()
 	health := chanLock.Poll(s.Ctx())
 
+	afk := time.NewTicker(afkCheckInterval)
+	defer afk.Stop()
+
+	suddenDeath := time.NewTicker(suddenDeathCheckInterval)
+	defer suddenDeath.Stop()
+
+	bots := time.NewTicker(botThinkInterval)
+	defer bots.Stop()
+
 	for {
 		select {
 		case <-s.Ctx().Done():
 			return
 		case <-health:
 			continue
+		case <-afk.C:
+			s.checkIdle()
+		case <-suddenDeath.C:
+			s.checkSuddenDeath()
+		case <-bots.C:
+			s.runBots()
+		case vote := <-s.voteTimeouts:
+			s.closeVote(vote)
+		case change := <-s.mapChanges:
+			s.changeMap(change)
+		case crc := <-s.mapCRCs:
+			s.applyMapCRC(crc)
+		case info := <-s.mapInfos:
+			s.applyMapInfo(info)
 		case msg := <-s.incoming:
 			client := s.Clients.GetClientByID(msg.Session)
 			if client == nil {
@@ -140,10 +257,14 @@ func (s *Server) GameDuration() time.Duration {
 	return time.Duration(s.Config.MatchLength) * time.Second
 }
 
+func (s *Server) GameSpeed() int32 {
+	return s.Speed
+}
+
 func (s *Server) Connect(sessionId uint32) (*Client, <-chan bool) {
 	existing := s.Clients.GetClientByID(sessionId)
 	if existing != nil {
-		log.Error().Msgf("client %d already connected")
+		log.Error().Msgf("client %d already connected", sessionId)
 		return nil, nil
 	}
 
@@ -152,6 +273,7 @@ func (s *Server) Connect(sessionId uint32) (*Client, <-chan bool) {
 	client := s.Clients.Add(sessionId, s.outgoing)
 	client.connected = connected
 	client.server = s
+	client.Account = s.account(client)
 	client.Positions, client.Packets = s.relay.AddClient(client.CN, func(channel uint8, payload []P.Message) {
 		s.outgoing <- ServerPacket{
 			Session:  client.SessionID,
@@ -161,7 +283,7 @@ func (s *Server) Connect(sessionId uint32) (*Client, <-chan bool) {
 	})
 
 	if client.Positions == nil {
-		log.Error().Msgf("client %d had no channels")
+		log.Error().Msgf("client %d had no channels", sessionId)
 		return nil, nil
 	}
 
@@ -170,7 +292,7 @@ func (s *Server) Connect(sessionId uint32) (*Client, <-chan bool) {
 			Client:      int32(client.CN),
 			Protocol:    P.PROTOCOL_VERSION,
 			SessionId:   int32(client.SessionID),
-			HasPassword: false, // password protection is not used by this implementation
+			HasPassword: s.Password != "",
 			Description: s.Description,
 			Domain:      "",
 		},
@@ -188,7 +310,7 @@ func (s *Server) RefreshServerInfo() {
 				Client:      int32(c.CN),
 				Protocol:    P.PROTOCOL_VERSION,
 				SessionId:   int32(c.SessionID),
-				HasPassword: false, // password protection is not used by this implementation
+				HasPassword: s.Password != "",
 				Description: s.Description,
 				Domain:      "",
 			},
@@ -217,6 +339,30 @@ func (s *Server) Resume() {
 	s.Clock.Pause(nil)
 }
 
+// Moves a player to or out of spectators.
+func (s *Server) SetSpectator(c *Client, spectating bool) {
+	if spectating {
+		if c.State == playerstate.Alive {
+			s.GameMode.HandleFrag(&c.Player, &c.Player)
+		}
+		s.GameMode.Leave(&c.Player)
+		s.Clock.Leave(&c.Player)
+		c.State = playerstate.Spectator
+	} else {
+		c.State = playerstate.Dead
+		c.active()
+		if teamedMode, ok := s.GameMode.(game.TeamMode); ok {
+			teamedMode.Join(&c.Player)
+		}
+	}
+	s.Clients.Broadcast(P.Spectator{Client: int32(c.CN), Spectating: spectating})
+	s.BalanceBots()
+	s.BalanceTeams()
+	if spectating {
+		s.leaveClanWar(c)
+	}
+}
+
 // Forcibly respawn a player. Passing nil respawns all non-spectating players.
 func (s *Server) ForceRespawn(target *Client) {
 	s.Clients.ForEach(func(c *Client) {
@@ -233,6 +379,11 @@ func (s *Server) ForceRespawn(target *Client) {
 	})
 }
 
+// Starts a new round of a round-based mode.
+func (s *Server) StartRound() {
+	s.ForceRespawn(nil)
+}
+
 // Kill all players, reset their scores (if resetFrags is true), and respawn them.
 func (s *Server) ResetPlayers(resetFrags bool) {
 	s.Clients.ForEach(func(c *Client) {
@@ -269,6 +420,7 @@ func (s *Server) TryJoin(c *Client, name string, playerModel int32, authDomain,
 func (s *Server) Join(c *Client) {
 	c.Joined = true
 	c.connected <- true
+	c.active()
 
 	if s.MasterMode == mastermode.Locked {
 		c.State = playerstate.Spectator
@@ -281,10 +433,26 @@ func (s *Server) Join(c *Client) {
 		teamedMode.Join(&c.Player) // may set client's team
 	}
 	s.SendWelcome(c) // tells client about her team
-	if flagMode, ok := s.GameMode.(game.FlagMode); ok {
-		c.Send(flagMode.FlagsInitPacket())
+	if messages := s.modeInitPacket(); len(messages) > 0 {
+		c.Send(messages...)
 	}
 	s.Clients.InformOthersOfJoin(c)
+	s.BalanceBots()
+}
+
+// Returns the state specific to the current mode, like flags or bases.
+func (s *Server) modeInitPacket() []P.Message {
+	messages := []P.Message{}
+	if flagMode, ok := s.GameMode.(game.FlagMode); ok {
+		messages = append(messages, flagMode.FlagsInitPacket())
+	}
+	if captureMode, ok := s.GameMode.(game.CaptureMode); ok {
+		messages = append(messages, captureMode.BasesInitPacket()...)
+	}
+	if collectMode, ok := s.GameMode.(game.CollectMode); ok {
+		messages = append(messages, collectMode.TokensInitPacket())
+	}
+	return messages
 }
 
 func (s *Server) Message(message string) {
@@ -302,6 +470,23 @@ func (s *Server) UniqueName(p *game.Player) string {
 func (s *Server) Spawn(client *Client) {
 	client.Spawn()
 	s.GameMode.Spawn(&client.PlayerState)
+	_, gunGame := s.GameMode.(*game.GunGame)
+	s.rules.spawn(&client.PlayerState, gunGame)
+}
+
+// Rearm spawns a living player again, which is the only way to change their
+// health, armour and weapons in the game. Like any spawn, it moves them to a
+// spawn point, ends their quad damage and clears their projectiles.
+func (s *Server) Rearm(p *game.Player) {
+	c := s.Clients.GetClientByCN(p.CN)
+	if c == nil || c.State != playerstate.Alive {
+		return
+	}
+
+	// they confirm the spawn like any other
+	c.State = playerstate.Dead
+	s.Spawn(c)
+	c.Send(P.SpawnState{Client: int32(c.CN), EntityState: c.ToWire()})
 }
 
 func (s *Server) ConfirmSpawn(client *Client, lifeSequence, _weapon int32) {
@@ -311,14 +496,14 @@ func (s *Server) ConfirmSpawn(client *Client, lifeSequence, _weapon int32) {
 	}
 
 	client.State = playerstate.Alive
-	client.SelectedWeapon = weapon.ByID(weapon.ID(_weapon))
+	client.SelectedWeapon = s.Weapon(weapon.ID(_weapon))
 	client.LastSpawnAttempt = time.Time{}
 
 	client.Packets.Publish(P.SpawnResponse{
 		EntityState: client.ToWire(),
 	})
 
-	if clock, competitive := s.GameMode.(game.Competitive); competitive {
+	if clock, competitive := s.Clock.(game.Competitive); competitive {
 		clock.Spawned(&client.Player)
 	}
 }
@@ -333,6 +518,8 @@ func (s *Server) Leave(sessionId uint32) {
 }
 
 func (s *Server) Disconnect(client *Client, reason disconnectreason.ID) {
+	s.saveDepartedStats(client)
+	s.withdrawVote(client)
 	s.GameMode.Leave(&client.Player)
 	s.Clock.Leave(&client.Player)
 	s.Clients.Disconnect(client, reason)
@@ -344,7 +531,15 @@ func (s *Server) Disconnect(client *Client, reason disconnectreason.ID) {
 		s.Unsupervised()
 	}
 	if s.Clients.GetNumClients() == 0 {
+		// there is no one left to play with the bots
+		if !client.IsBot() {
+			s.removeBots()
+		}
 		s.Empty()
+	} else if !client.IsBot() {
+		s.BalanceBots()
+		s.BalanceTeams()
+		s.leaveClanWar(client)
 	}
 }
 
@@ -358,7 +553,7 @@ func (s *Server) Kick(client *Client, victim *Client, reason string) {
 		msg += " for: " + reason
 	}
 	s.Message(msg)
-	s.Disconnect(victim, disconnectreason.Kick)
+	s.kick(victim, msg)
 }
 
 func (s *Server) AuthKick(client *Client, rol role.ID, domain, name string, victim *Client, reason string) {
@@ -371,14 +566,16 @@ func (s *Server) AuthKick(client *Client, rol role.ID, domain, name string, vict
 		msg += " for: " + reason
 	}
 	s.Message(msg)
-	s.Disconnect(victim, disconnectreason.Kick)
+	s.kick(victim, msg)
 }
 
 func (s *Server) Unsupervised() {
+	s.endClanWar()
 	s.Clock.Resume(nil)
 	s.MasterMode = mastermode.Auth
 	s.KeepTeams = false
 	s.CompetitiveMode = false
+	s.TeamsLocked = false
 	s.ReportStats = true
 }
 
@@ -388,18 +585,54 @@ func (s *Server) Empty() {
 }
 
 func (s *Server) Intermission() {
+	if s.overtime() {
+		return
+	}
+
 	s.Clock.Stop()
+	s.stopDemo()
+	s.clanWarScoreboard()
+	s.reportStats()
+	s.suggestSwap()
 
-	allMaps := make([]string, 0)
-	allMaps = append(allMaps, s.Maps...)
-	allMaps = append(allMaps, s.DefaultMap)
-	nextMap := allMaps[s.rng.Uint32()%uint32(len(allMaps))]
+	next := s.nextInRotation()
 
-	s.pendingMapChange = time.AfterFunc(10*time.Second, func() {
-		s.StartGame(s.StartMode(s.GameMode.ID()), nextMap)
+	// the map is changed in the server's loop, where the votes are counted
+	change := &mapChange{next: next}
+	change.timer = time.AfterFunc(10*time.Second, func() {
+		select {
+		case s.mapChanges <- change:
+		case <-s.Ctx().Done():
+		}
 	})
+	s.pendingMapChange = change
+
+	if option, ok := s.voteWinner(); ok {
+		s.Message(fmt.Sprintf("next up: %s, unless you vote for something else", option))
+		return
+	}
+	s.Message(fmt.Sprintf("next up: %s, unless you vote for something else", next))
+}
+
+// The change to the next map at the end of intermission.
+type mapChange struct {
+	// what is played next, unless players vote for something else
+	next  voteOption
+	timer *time.Timer
+}
+
+func (s *Server) changeMap(change *mapChange) {
+	// the game might have been changed in the meantime
+	if s.pendingMapChange != change {
+		return
+	}
 
-	s.Message("next up: " + nextMap)
+	if option, ok := s.voteWinner(); ok {
+		s.Message(fmt.Sprintf("vote passed: %s", option))
+		s.StartGame(s.StartMode(option.mode), option.map_)
+		return
+	}
+	s.StartGame(s.StartMode(change.next.mode), change.next.map_)
 }
 
 // Returns the number of connected clients playing (i.e. joined and not spectating)
@@ -433,7 +666,10 @@ func (s *Server) StartGame(mode game.Mode, mapname string) {
 	if s.Clock != nil {
 		s.Clock.CleanUp()
 	}
-	if s.CompetitiveMode {
+	s.stopDemo()
+	// round-based modes wait for everyone to load the map, too
+	_, rounds := mode.(game.RoundMode)
+	if s.CompetitiveMode || s.ClanWar || rounds {
 		s.Clock = game.NewCompetitiveClock(s, mode)
 	} else if mode.ID() == gamemode.CoopEdit {
 		s.Clock = game.NewEndlessClock(s, mode)
@@ -443,13 +679,22 @@ func (s *Server) StartGame(mode game.Mode, mapname string) {
 
 	// stop any pending map change
 	if s.pendingMapChange != nil {
-		s.pendingMapChange.Stop()
+		s.pendingMapChange.timer.Stop()
 		s.pendingMapChange = nil
 	}
 
+	// what players taught the bots is only good for the same map
+	if mapname != s.Map {
+		s.nav = newNavigation()
+	}
+
 	s.Map = mapname
 	s.GameMode = mode
+	s.rememberMap(mapname)
 
+	s.resetVote()
+	s.resetMapCRC()
+	s.mapData = nil
 	s.maps <- mapname
 
 	if teamedMode, ok := s.GameMode.(game.TeamMode); ok {
@@ -465,8 +710,12 @@ func (s *Server) StartGame(mode game.Mode, mapname string) {
 	)
 
 	s.Clock.Start()
+	s.startClanWar()
+	s.resetStats()
 
 	s.MapChange()
+	s.BalanceBots()
+	s.startDemo()
 }
 
 func (s *Server) SetMasterMode(c *Client, mm mastermode.ID) {
@@ -514,16 +763,20 @@ func (s *Server) HandleShoot(client *Client, wpn weapon.Weapon, id int32, from,
 		},
 	)
 	client.LastShot = time.Now()
-	client.DamagePotential += wpn.Damage * wpn.Rays // TODO: quad damage
+	client.DamagePotential += client.ScaleDamage(wpn.Damage * wpn.Rays)
+	client.Stats.Shot(wpn.ID, client.ScaleDamage(wpn.Damage*wpn.Rays))
 	if wpn.ID != weapon.Saw {
 		client.Ammo[wpn.ID]--
 	}
 	switch wpn.ID {
 	case weapon.GrenadeLauncher, weapon.RocketLauncher:
-		// wait for nmc.Explode pkg
+		// damage is applied once the client tells us where the projectile
+		// exploded
+		client.AddProjectile(id, wpn.ID, from)
 	default:
 		// apply damage
 		rays := int32(0)
+		impossible := false
 		for _, h := range hits {
 			target := s.Clients.GetClientByCN(h.target)
 			if target == nil ||
@@ -534,21 +787,38 @@ func (s *Server) HandleShoot(client *Client, wpn weapon.Weapon, id int32, from,
 				continue
 			}
 
+			if !s.validHit(client, target, wpn, from, to) {
+				impossible = true
+				continue
+			}
+
 			rays += h.rays
 			if rays > wpn.Rays {
 				continue
 			}
 
-			damage := h.rays * wpn.Damage
-			// TODO: quad damage
+			damage := client.ScaleDamage(h.rays * wpn.Damage)
 
 			s.applyDamage(client, target, int32(damage), wpn.ID, h.dir)
 		}
+
+		// there is no one to punish for the shots of bots
+		if impossible && !client.IsBot() {
+			s.suspect(client, &client.impossibleHits, "hitting players out of the line of fire")
+		}
 	}
 }
 
 func (s *Server) HandleExplode(client *Client, millis int32, wpn weapon.Weapon, id int32, hits []hit) {
-	// TODO: delete stored projectile
+	projectile, ok := client.TakeProjectile(id, wpn.ID)
+	if !ok {
+		log.Debug().Msgf("%s exploded unknown projectile %d", client, id)
+		return
+	}
+	if !projectile.Plausible(s.Speed) {
+		log.Debug().Msgf("%s exploded projectile %d too late (at %d)", client, id, millis)
+		return
+	}
 
 	s.Clients.Relay(
 		client,
@@ -578,8 +848,7 @@ hits:
 			}
 		}
 
-		damage := float64(wpn.Damage)
-		// TODO: quad damage
+		damage := float64(client.ScaleDamage(wpn.Damage))
 		damage *= (1 - h.distance/weapon.ExplosionDistanceScale/wpn.ExplosionRadius)
 		if target == client {
 			damage *= weapon.ExplosionSelfDamageScale
@@ -591,6 +860,12 @@ hits:
 
 func (s *Server) applyDamage(attacker, victim *Client, damage int32, wpnID weapon.ID, dir *geom.Vector) {
 	victim.ApplyDamage(&attacker.Player, damage, wpnID, dir)
+	victim.Stats.DamageReceived += damage
+	if attacker != victim && attacker.Team != victim.Team {
+		attacker.Stats.Hit(wpnID, damage)
+	}
+	// knockback can send the victim flying
+	victim.movement.grace()
 	s.Clients.Broadcast(
 		P.Damage{
 			int32(victim.CN),
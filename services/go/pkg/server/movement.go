@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+)
+
+// Clients simulate their own physics and only tell us where they ended up, so
+// a modified client can move however it likes. We compare the positions
+// clients send in N_POS against what the vanilla physics allow and act on
+// players that keep breaking the rules. We can't see the map, so we can't
+// tell where players may go up and down (swimming, for one, goes anywhere
+// in the water) and only check how fast they move across it.
+
+const (
+	// units per second, maxspeed in the game's physics.cpp
+	maxPlayerSpeed = 100.0
+	// allows for strafe jumping, lag and the rounding of positions
+	speedTolerance = 1.5
+	// how long we measure the speed of a player over
+	speedWindow = time.Second
+	// how far a player may move between two position updates
+	maxStepDistance = 256.0
+	// jumppads, teleporters, spawning and explosions move players in ways we
+	// can't predict, so we stop checking for a while after them
+	movementGracePeriod = 3 * time.Second
+)
+
+// physent::physstate in the game: the player stands on the ground
+const physFloor = 4
+
+type movement struct {
+	lifeSequence int32
+	tracking     bool
+
+	last P.Vec
+
+	// the position at the start of the current speed window
+	windowStart     P.Vec
+	windowStartTime time.Time
+
+	graceUntil time.Time
+
+	violations violations
+}
+
+// Stops checking the movement of the player for a while, for example because
+// they used a jumppad.
+func (m *movement) grace() {
+	m.graceUntil = time.Now().Add(movementGracePeriod)
+}
+
+func horizontalDistance(a, b P.Vec) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// Checks a position update of c against the physics and returns the reason
+// if it was impossible.
+func (s *Server) checkMovement(c *Client, state P.PhysicsState) string {
+	m := &c.movement
+	now := time.Now()
+	position := state.O
+
+	// a new life (or our first update) starts wherever the player spawned
+	if !m.tracking || m.lifeSequence != c.LifeSequence {
+		m.tracking = true
+		m.lifeSequence = c.LifeSequence
+		m.last = position
+		m.windowStart = position
+		m.windowStartTime = now
+		m.grace()
+		return ""
+	}
+
+	last := m.last
+	m.last = position
+
+	if now.Before(m.graceUntil) {
+		m.windowStart = position
+		m.windowStartTime = now
+		return ""
+	}
+
+	if horizontalDistance(position, last) > maxStepDistance {
+		m.windowStart = position
+		m.windowStartTime = now
+		return "teleporting without a teleporter"
+	}
+
+	elapsed := now.Sub(m.windowStartTime)
+	if elapsed < speedWindow {
+		return ""
+	}
+
+	speed := horizontalDistance(position, m.windowStart) / elapsed.Seconds()
+	m.windowStart = position
+	m.windowStartTime = now
+
+	// the game runs faster or slower in real time with the game speed
+	limit := maxPlayerSpeed * speedTolerance * float64(s.Speed) / defaultGameSpeed
+	if speed > limit {
+		return fmt.Sprintf("moving too fast (%.0f units/s)", speed)
+	}
+
+	return ""
+}
+
+// Validates the position c sent and punishes them if they keep moving in
+// impossible ways. Returns false if the position should not be passed on to
+// the other clients.
+func (s *Server) HandleMovement(c *Client, state P.PhysicsState) bool {
+	// bots only go where players went before them and
+	// editors fly around freely
+	if c.IsBot() || c.State != playerstate.Alive || s.GameMode.ID() == gamemode.CoopEdit {
+		return true
+	}
+
+	reason := s.checkMovement(c, state)
+	if reason == "" {
+		return true
+	}
+
+	return s.suspect(c, &c.movement.violations, reason)
+}
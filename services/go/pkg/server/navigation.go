@@ -0,0 +1,303 @@
+package server
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+
+	C "github.com/cfoust/sour/pkg/game/constants"
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/geom"
+)
+
+// Bots can't see the geometry of the map, so they only go where players went
+// before them. Like the waypoints vanilla clients drop behind players, we
+// remember where players walk and which of those places they walked between.
+// The map's entities (player starts, pickups and teleports) are where bots
+// spawn, what they walk to, and how they get across the map quickly.
+
+const (
+	// players leave a waypoint behind whenever they are this far from the
+	// closest one, like WAYPOINTRADIUS in the game's ai.h
+	waypointRadius = 16.0
+	// spawning and teleports move players farther than this between two
+	// position updates, which must not link the waypoints
+	maxLinkDistance = 48.0
+	// an entity can be reached from the waypoints this close to it
+	entityRadius = 32.0
+	// how close players have to get to pickups to take them
+	pickupRadius = 16.0
+	// keeps the time it takes to find routes in check
+	maxWaypoints = 8192
+	// waypoints are sorted into cubes this big to find them quickly
+	waypointCellSize = 64.0
+)
+
+type waypoint struct {
+	position *geom.Vector
+	// the waypoints players walked to from here
+	links []int
+}
+
+type waypointCell struct {
+	x, y, z int
+}
+
+func cellOf(position *geom.Vector) waypointCell {
+	return waypointCell{
+		x: int(math.Floor(position.X() / waypointCellSize)),
+		y: int(math.Floor(position.Y() / waypointCellSize)),
+		z: int(math.Floor(position.Z() / waypointCellSize)),
+	}
+}
+
+// where a player left their last waypoint
+type trail struct {
+	lifeSequence int32
+	waypoint     int
+}
+
+type teleport struct {
+	source       MapEntity
+	destinations []MapEntity
+}
+
+type navigation struct {
+	waypoints []*waypoint
+	cells     map[waypointCell][]int
+	trails    map[uint32]trail
+
+	starts    []MapEntity
+	pickups   map[int32]MapEntity
+	teleports []teleport
+}
+
+func newNavigation() *navigation {
+	return &navigation{
+		cells:   map[waypointCell][]int{},
+		trails:  map[uint32]trail{},
+		pickups: map[int32]MapEntity{},
+	}
+}
+
+// The position of the eyes of a player standing on the entity, which is what
+// clients send in N_POS.
+func standingOn(e MapEntity) *geom.Vector {
+	return e.Position.Add(geom.NewVector(0, 0, C.DEFAULT_EYE_HEIGHT))
+}
+
+func (n *navigation) setEntities(entities []MapEntity) {
+	n.starts = nil
+	n.pickups = map[int32]MapEntity{}
+	n.teleports = nil
+
+	destinations := map[int16][]MapEntity{}
+	for _, e := range entities {
+		switch {
+		case e.Type == C.EntityTypePlayerStart:
+			n.starts = append(n.starts, e)
+		case e.Type >= C.EntityTypeShells && e.Type <= C.EntityTypeQuad:
+			n.pickups[e.Index] = e
+		case e.Type == C.EntityTypeTeledest:
+			destinations[e.Attr2] = append(destinations[e.Attr2], e)
+		}
+	}
+
+	for _, e := range entities {
+		if e.Type == C.EntityTypeTeleport && len(destinations[e.Attr1]) > 0 {
+			n.teleports = append(n.teleports, teleport{
+				source:       e,
+				destinations: destinations[e.Attr1],
+			})
+		}
+	}
+}
+
+// spawnPoint picks one of the player starts of the team, or of no team if
+// the map has none for it.
+func (n *navigation) spawnPoint(team string, rng *rand.Rand) (MapEntity, bool) {
+	var tag int16
+	switch team {
+	case "good":
+		tag = 1
+	case "evil":
+		tag = 2
+	}
+
+	var starts, teamStarts []MapEntity
+	for _, e := range n.starts {
+		switch {
+		case e.Attr2 == 0:
+			starts = append(starts, e)
+		case tag != 0 && e.Attr2 == tag:
+			teamStarts = append(teamStarts, e)
+		}
+	}
+	if len(teamStarts) > 0 {
+		starts = teamStarts
+	}
+	if len(starts) == 0 {
+		starts = n.starts
+	}
+	if len(starts) == 0 {
+		return MapEntity{}, false
+	}
+	return starts[rng.Intn(len(starts))], true
+}
+
+// nearest returns the waypoint closest to position that is at most radius
+// away, or -1 if there is none. radius must not exceed waypointCellSize.
+func (n *navigation) nearest(position *geom.Vector, radius float64) int {
+	best, bestDistance := -1, radius
+	center := cellOf(position)
+	for x := center.x - 1; x <= center.x+1; x++ {
+		for y := center.y - 1; y <= center.y+1; y++ {
+			for z := center.z - 1; z <= center.z+1; z++ {
+				for _, i := range n.cells[waypointCell{x, y, z}] {
+					distance := geom.Distance(n.waypoints[i].position, position)
+					if distance <= bestDistance {
+						best, bestDistance = i, distance
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+func (n *navigation) link(from, to int) {
+	if from == to {
+		return
+	}
+	for _, linked := range n.waypoints[from].links {
+		if linked == to {
+			return
+		}
+	}
+	n.waypoints[from].links = append(n.waypoints[from].links, to)
+}
+
+// follow drops waypoints behind the player c, who just moved to o.
+func (n *navigation) follow(c *Client, o P.Vec) {
+	position := mapVec(o)
+
+	current := n.nearest(position, waypointRadius)
+	if current < 0 {
+		if len(n.waypoints) >= maxWaypoints {
+			delete(n.trails, c.CN)
+			return
+		}
+		current = len(n.waypoints)
+		n.waypoints = append(n.waypoints, &waypoint{position: position})
+		cell := cellOf(position)
+		n.cells[cell] = append(n.cells[cell], current)
+	}
+
+	last, ok := n.trails[c.CN]
+	if ok && last.lifeSequence == c.LifeSequence && geom.Distance(n.waypoints[last.waypoint].position, position) <= maxLinkDistance {
+		n.link(last.waypoint, current)
+	}
+	n.trails[c.CN] = trail{lifeSequence: c.LifeSequence, waypoint: current}
+}
+
+// One leg of a bot's way across the map: either walking to a waypoint, or
+// taking a teleport and walking to the waypoint next to where it leads.
+type step struct {
+	waypoint int
+	teleport *MapEntity
+	teledest *MapEntity
+}
+
+type route struct {
+	distance float64
+	// the waypoint the route comes from, -1 if it starts here
+	previous int
+	step     step
+}
+
+type routeQueue struct {
+	routes []route
+	queue  []int
+}
+
+func (q *routeQueue) Len() int { return len(q.queue) }
+func (q *routeQueue) Less(i, j int) bool {
+	return q.routes[q.queue[i]].distance < q.routes[q.queue[j]].distance
+}
+func (q *routeQueue) Swap(i, j int)      { q.queue[i], q.queue[j] = q.queue[j], q.queue[i] }
+func (q *routeQueue) Push(x interface{}) { q.queue = append(q.queue, x.(int)) }
+func (q *routeQueue) Pop() interface{} {
+	last := q.queue[len(q.queue)-1]
+	q.queue = q.queue[:len(q.queue)-1]
+	return last
+}
+
+// The ways out of a waypoint: the links players left, and the teleports next
+// to it.
+func (n *navigation) steps(from int) []step {
+	steps := make([]step, 0, len(n.waypoints[from].links))
+	for _, to := range n.waypoints[from].links {
+		steps = append(steps, step{waypoint: to})
+	}
+
+	position := n.waypoints[from].position
+	for i := range n.teleports {
+		t := &n.teleports[i]
+		if geom.Distance(standingOn(t.source), position) > entityRadius {
+			continue
+		}
+		for j := range t.destinations {
+			to := n.nearest(standingOn(t.destinations[j]), entityRadius)
+			if to >= 0 {
+				steps = append(steps, step{waypoint: to, teleport: &t.source, teledest: &t.destinations[j]})
+			}
+		}
+	}
+	return steps
+}
+
+// routes finds the shortest routes from the waypoint from to all others.
+// Unreachable waypoints are infinitely far away.
+func (n *navigation) routes(from int) []route {
+	routes := make([]route, len(n.waypoints))
+	for i := range routes {
+		routes[i] = route{distance: math.Inf(1), previous: -1}
+	}
+	routes[from].distance = 0
+
+	done := make([]bool, len(n.waypoints))
+	q := &routeQueue{routes: routes, queue: []int{from}}
+	for q.Len() > 0 {
+		current := heap.Pop(q).(int)
+		if done[current] {
+			continue
+		}
+		done[current] = true
+
+		for _, s := range n.steps(current) {
+			distance := routes[current].distance
+			if s.teleport == nil {
+				distance += geom.Distance(n.waypoints[current].position, n.waypoints[s.waypoint].position)
+			}
+			if distance >= routes[s.waypoint].distance {
+				continue
+			}
+			routes[s.waypoint] = route{distance: distance, previous: current, step: s}
+			heap.Push(q, s.waypoint)
+		}
+	}
+
+	return routes
+}
+
+// path returns the steps along routes to the waypoint to.
+func path(routes []route, to int) []step {
+	steps := []step{}
+	for i := to; routes[i].previous >= 0; i = routes[i].previous {
+		steps = append(steps, routes[i].step)
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
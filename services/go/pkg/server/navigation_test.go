@@ -0,0 +1,156 @@
+package server
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	C "github.com/cfoust/sour/pkg/game/constants"
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/geom"
+	"github.com/stretchr/testify/assert"
+)
+
+// Walks c along the X axis from one position to the next.
+func walk(n *navigation, c *Client, xs ...float64) {
+	for _, x := range xs {
+		n.follow(c, P.Vec{X: x, Y: 0, Z: C.DEFAULT_EYE_HEIGHT})
+	}
+}
+
+func TestFollow(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// the positions the player walks to
+		xs []float64
+		// if set, the player dies after walking to the first position
+		dies      bool
+		waypoints int
+		links     [][]int
+	}{
+		{
+			name:      "drops waypoints",
+			xs:        []float64{0, 10, 20, 30, 40},
+			waypoints: 3,
+			links:     [][]int{{1}, {2}, nil},
+		},
+		{
+			name:      "walking back",
+			xs:        []float64{0, 20, 0},
+			waypoints: 2,
+			links:     [][]int{{1}, {0}},
+		},
+		{
+			name:      "teleported",
+			xs:        []float64{0, 500},
+			waypoints: 2,
+			links:     [][]int{nil, nil},
+		},
+		{
+			name:      "respawned",
+			xs:        []float64{0, 20},
+			dies:      true,
+			waypoints: 2,
+			links:     [][]int{nil, nil},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n := newNavigation()
+			c := newTestClient(1, 0)
+
+			walk(n, c, test.xs[0])
+			if test.dies {
+				c.LifeSequence++
+			}
+			walk(n, c, test.xs[1:]...)
+
+			assert.Len(t, n.waypoints, test.waypoints)
+			for i, links := range test.links {
+				assert.Equal(t, links, n.waypoints[i].links)
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	n := newNavigation()
+	c := newTestClient(1, 0)
+
+	// two separate corridors, joined by a teleport
+	walk(n, c, 0, 20, 40, 60)
+	c.LifeSequence++
+	walk(n, c, 1000, 1020, 1040)
+
+	n.setEntities([]MapEntity{
+		{Index: 3, Type: C.EntityTypeTeleport, Position: geom.NewVector(80, 0, 0), Attr1: 7},
+		{Index: 4, Type: C.EntityTypeTeledest, Position: geom.NewVector(995, 0, 0), Attr2: 7},
+		{Index: 5, Type: C.EntityTypeHealth, Position: geom.NewVector(1040, 0, 0)},
+	})
+
+	routes := n.routes(0)
+	item := n.nearest(standingOn(n.pickups[5]), entityRadius)
+	assert.Equal(t, 6, item)
+	assert.Equal(t, 60.0+40.0, routes[item].distance)
+
+	steps := path(routes, item)
+	assert.Len(t, steps, 6)
+	assert.Nil(t, steps[2].teleport)
+	assert.Equal(t, int32(3), steps[3].teleport.Index)
+	assert.Equal(t, int32(4), steps[3].teledest.Index)
+	assert.Equal(t, 4, steps[3].waypoint)
+
+	// there's no way back
+	routes = n.routes(item)
+	assert.True(t, math.IsInf(routes[0].distance, 1))
+	assert.Empty(t, path(routes, item))
+}
+
+func TestSpawnPoint(t *testing.T) {
+	start := func(index int32, team int16) MapEntity {
+		return MapEntity{Index: index, Type: C.EntityTypePlayerStart, Position: geom.NewVector(0, 0, 0), Attr2: team}
+	}
+
+	for _, test := range []struct {
+		name     string
+		entities []MapEntity
+		team     string
+		want     []int32
+	}{
+		{
+			name: "no player starts",
+			team: "good",
+		},
+		{
+			name:     "team starts",
+			entities: []MapEntity{start(1, 0), start(2, 1), start(3, 2)},
+			team:     "evil",
+			want:     []int32{3},
+		},
+		{
+			name:     "no team",
+			entities: []MapEntity{start(1, 0), start(2, 1), start(3, 2)},
+			team:     "none",
+			want:     []int32{1},
+		},
+		{
+			name:     "only other teams' starts",
+			entities: []MapEntity{start(2, 1)},
+			team:     "none",
+			want:     []int32{2},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n := newNavigation()
+			n.setEntities(test.entities)
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 10; i++ {
+				e, ok := n.spawnPoint(test.team, rng)
+				assert.Equal(t, len(test.want) > 0, ok)
+				if ok {
+					assert.Contains(t, test.want, e.Index)
+				}
+			}
+		})
+	}
+}
@@ -43,8 +43,34 @@ func mapHits(hits []P.Hit) []hit {
 	return result
 }
 
+// messages the AI of a bot may send
+var botMessages = map[P.MessageCode]struct{}{
+	P.N_POS:           {},
+	P.N_JUMPPAD:       {},
+	P.N_TELEPORT:      {},
+	P.N_TRYSPAWN:      {},
+	P.N_SPAWN:         {},
+	P.N_GUNSELECT:     {},
+	P.N_SHOOT:         {},
+	P.N_EXPLODE:       {},
+	P.N_SUICIDE:       {},
+	P.N_SOUND:         {},
+	P.N_TAUNT:         {},
+	P.N_ITEMPICKUP:    {},
+	P.N_TAKEFLAG:      {},
+	P.N_TRYDROPFLAG:   {},
+	P.N_TAKETOKEN:     {},
+	P.N_DEPOSITTOKENS: {},
+	P.N_REPAMMO:       {},
+}
+
 // checks if the client is allowed to send a certain type of message to us.
 func isValidMessage(c *Client, code P.MessageCode) bool {
+	if c.IsBot() {
+		_, ok := botMessages[code]
+		return ok
+	}
+
 	if code == P.N_PING {
 		return true
 	}
@@ -65,8 +91,15 @@ func isValidMessage(c *Client, code P.MessageCode) bool {
 
 // parses a packet and decides what to do based on the network message code at the front of the packet
 func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message) {
-	// this implementation does not support channel 2 (for coop edit purposes) yet.
-	if client == nil || 0 > channelID || channelID > 1 {
+	if client == nil || 0 > channelID || channelID > 2 {
+		return
+	}
+
+	// channel 2 is only used to upload maps in coop edit
+	if channelID == 2 {
+		if sendMap, ok := message.(P.SendMap); ok && client.Joined && !client.IsBot() {
+			s.ReceiveMapData(client, sendMap.Map)
+		}
 		return
 	}
 
@@ -78,6 +111,9 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 
 	if !isValidMessage(client, packetType) {
 		log.Println("invalid network message code", packetType, "from CN", client.CN)
+		if client.IsBot() {
+			return
+		}
 		s.Disconnect(client, disconnectreason.MessageError)
 		return
 	}
@@ -87,6 +123,7 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 			return
 		}
 
+		client.active()
 		s.Clients.Broadcast(message)
 
 		s.Edits.Publish(MapEdit{
@@ -105,14 +142,26 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		// client sending his position and movement in the world
 		if client.State == playerstate.Alive {
 			msg.State.LifeSequence = client.LifeSequence
+			if !s.HandleMovement(client, msg.State) {
+				return
+			}
 			client.Positions.Publish(msg)
-			client.Position = mapVec(msg.State.O)
+			position := mapVec(msg.State.O)
+			if client.Position == nil || geom.Distance(client.Position, position) > 0 {
+				client.active()
+			}
+			client.Position = position
+			client.history.add(client.LifeSequence, client.Position)
+			if !client.IsBot() {
+				s.nav.follow(client, msg.State.O)
+			}
 		}
 		return
 
 	case P.N_JUMPPAD:
 		msg := message.(P.JumpPad)
 		if client.State == playerstate.Alive {
+			client.movement.grace()
 			s.relay.FlushPositionAndSend(client.CN, msg)
 		}
 
@@ -120,11 +169,39 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		msg := message.(P.Teleport)
 
 		if client.State == playerstate.Alive {
+			client.movement.grace()
 			s.relay.FlushPositionAndSend(client.CN, msg)
 		}
 
-	case P.N_ADDBOT, P.N_DELBOT:
-		client.Message("bots currently not supported")
+	case P.N_ADDBOT:
+		msg := message.(P.AddBot)
+		if client.Role == role.None {
+			client.Message(cubecode.Fail("you can't do that"))
+			return
+		}
+
+		limit := s.BotLimit
+		if client.Role >= role.Admin {
+			limit = maxBots
+		}
+		if !s.AddBot(msg.NumBots, limit) {
+			client.Message(cubecode.Fail("failed to create or assign bot"))
+		}
+
+	case P.N_DELBOT:
+		if client.Role == role.None {
+			client.Message(cubecode.Fail("you can't do that"))
+			return
+		}
+		s.RemoveBot()
+
+	case P.N_BOTLIMIT:
+		msg := message.(P.BotLimit)
+		s.SetBotLimit(client, msg.Limit)
+
+	case P.N_BOTBALANCE:
+		msg := message.(P.BotBalance)
+		s.SetBotBalance(client, msg.Balance != 0)
 
 	// channel 1 traffic
 	case P.N_CONNECT:
@@ -145,6 +222,22 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 			s.setRole(client, cn, role.Master)
 		}
 
+	case P.N_AUTHTRY:
+		msg := message.(P.AuthTry)
+		s.TryAuth(client, msg.Description, msg.Answer, nil)
+
+	case P.N_AUTHKICK:
+		msg := message.(P.AuthKick)
+		victim := s.Clients.GetClientByCN(uint32(msg.Victim))
+		if victim == nil || victim == client {
+			return
+		}
+		s.TryAuth(client, msg.Description, msg.Answer, victim)
+
+	case P.N_AUTHANS:
+		msg := message.(P.AuthAns)
+		s.AnswerAuth(client, msg.Description, uint32(msg.Id), msg.Answer)
+
 	case P.N_KICK:
 		msg := message.(P.Kick)
 
@@ -157,6 +250,9 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 
 		s.Kick(client, victim, msg.Reason)
 
+	case P.N_CLEARBANS:
+		s.ClearBans(client)
+
 	case P.N_MASTERMODE:
 		msg := message.(P.MasterMode)
 		mm := mastermode.ID(msg.MasterMode)
@@ -187,22 +283,13 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 			// nothing to do
 			return
 		}
-
-		if toggle {
-			if client.State == playerstate.Alive {
-				s.GameMode.HandleFrag(&spectator.Player, &spectator.Player)
-			}
-			s.GameMode.Leave(&spectator.Player)
-			s.Clock.Leave(&spectator.Player)
-			spectator.State = playerstate.Spectator
-		} else {
-			spectator.State = playerstate.Dead
-			if teamedMode, ok := s.GameMode.(game.TeamMode); ok {
-				teamedMode.Join(&spectator.Player)
-			}
-			// todo: checkmap
+		// only privileged clients can let players with a modified map play
+		if !toggle && spectator.ModifiedMap && s.CompetitiveMode && client.Role == role.None {
+			client.Message(cubecode.Fail(fmt.Sprintf("%s has a modified map", s.Clients.UniqueName(spectator))))
+			return
 		}
-		s.Clients.Broadcast(P.Spectator{int32(spectator.CN), toggle})
+
+		s.SetSpectator(spectator, toggle)
 
 	case P.N_MAPVOTE:
 		msg := message.(P.MapVote)
@@ -221,7 +308,7 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		}
 
 		if s.MasterMode < mastermode.Veto {
-			client.Message(cubecode.Fail("this server does not support map voting"))
+			s.Vote(client, modeID, mapname)
 			return
 		}
 
@@ -239,6 +326,7 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 
 		// client pinging server → send pong
 		client.Send(P.Pong{msg.Cmillis})
+		client.pongSent = time.Now()
 
 	case P.N_CLIENTPING:
 		msg := message.(P.ClientPing)
@@ -246,11 +334,14 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		// client sending the amount of lag he measured to the server → broadcast to other clients
 		client.Ping = int32(msg.Ping)
 		client.Packets.Publish(P.ClientPing{int32(client.Ping)})
+		client.measureLatency()
 
 	case P.N_TEXT:
+		client.active()
 		client.Packets.Publish(message.(P.Text))
 
 	case P.N_SAYTEAM:
+		client.active()
 		// client sending team chat message → pass on to team immediately
 		msg := message.(P.SayTeam).Text
 		s.Clients.SendToTeam(client, P.SayTeam{msg})
@@ -282,7 +373,7 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		}
 
 		teamMode, ok := s.GameMode.(game.TeamMode)
-		if !ok {
+		if !ok || !s.canSwitchTeam(client, teamName) {
 			return
 		}
 
@@ -306,7 +397,11 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		teamMode.ChangeTeam(&victim.Player, teamName, true)
 
 	case P.N_MAPCRC:
-		// TODO
+		msg := message.(P.MapCRC)
+		s.HandleMapCRC(client, msg.Map, msg.Crc)
+
+	case P.N_CHECKMAPS:
+		s.CheckMaps(client)
 
 	case P.N_TRYSPAWN:
 		if !client.Joined || client.State != playerstate.Dead || !client.LastSpawnAttempt.IsZero() || !s.GameMode.CanSpawn(&client.Player) {
@@ -333,8 +428,9 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 
 	case P.N_SHOOT:
 		msg := message.(P.Shoot)
+		client.active()
 
-		wpn := weapon.ByID(weapon.ID(msg.Gun))
+		wpn := s.Weapon(weapon.ID(msg.Gun))
 		if time.Now().Before(client.GunReloadEnd) || client.Ammo[wpn.ID] <= 0 {
 			return
 		}
@@ -342,8 +438,11 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		from := mapVec(msg.From)
 		to := mapVec(msg.To)
 
-		if dist := geom.Distance(from, to); dist > wpn.Range+1.0 {
-			log.Println("shot distance out of weapon's range: distane =", dist, "range =", wpn.Range+1)
+		// clients shoot as far as the stock weapon reaches, even if it hits
+		// less far on this server
+		stockRange := weapon.ByID(wpn.ID).Range
+		if dist := geom.Distance(from, to); dist > stockRange+1.0 {
+			log.Println("shot distance out of weapon's range: distane =", dist, "range =", stockRange+1)
 			return
 		}
 
@@ -358,7 +457,7 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 
 	case P.N_EXPLODE:
 		msg := message.(P.Explode)
-		wpn := weapon.ByID(weapon.ID(msg.Gun))
+		wpn := s.Weapon(weapon.ID(msg.Gun))
 		s.HandleExplode(client, int32(msg.Cmillis), wpn, int32(msg.Id), mapHits(msg.Hits))
 
 	case P.N_SUICIDE:
@@ -368,6 +467,35 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 		msg := message.(P.Sound)
 		client.Packets.Publish(msg)
 
+	case P.N_RECORDDEMO:
+		msg := message.(P.RecordDemo)
+		s.SetRecordDemos(client, msg.Enabled != 0)
+
+	case P.N_STOPDEMO:
+		if client.Role == role.None {
+			client.Message(cubecode.Fail("you can't do that"))
+			return
+		}
+		s.stopDemo()
+
+	case P.N_LISTDEMOS:
+		s.SendDemoList(client)
+
+	case P.N_GETDEMO:
+		msg := message.(P.GetDemo)
+		s.SendDemo(client, msg.Demo, msg.Tag)
+
+	case P.N_CLEARDEMOS:
+		msg := message.(P.ClearDemos)
+		s.ClearDemos(client, msg.Demo)
+
+	case P.N_GETMAP:
+		s.SendMapData(client)
+
+	case P.N_GAMESPEED:
+		msg := message.(P.GameSpeed)
+		s.SetGameSpeed(client, msg.Speed)
+
 	case P.N_PAUSEGAME:
 		msg := message.(P.PauseGame)
 		if s.MasterMode < mastermode.Locked {
@@ -375,6 +503,9 @@ func (s *Server) HandlePacket(client *Client, channelID uint8, message P.Message
 				return
 			}
 		}
+		if !s.canPause(client, msg.Paused) {
+			return
+		}
 		if msg.Paused {
 			s.Clock.Pause(&client.Player)
 		} else {
@@ -24,3 +24,24 @@ var Pickups map[ID]Pickup = map[ID]Pickup{
 	PickupYellowArmor:     Pickup{PickupYellowArmor, sound.PickUpArmour, 200, 200},
 	PickupQuadDamage:      Pickup{PickupQuadDamage, sound.PickUpQuaddamage, 20000, 30000},
 }
+
+var pickupNames = map[string]ID{
+	"shells":       PickupShotgun,
+	"bullets":      PickupMinigun,
+	"rockets":      PickupRocketLauncher,
+	"riflerounds":  PickupRifle,
+	"grenades":     PickupGrenadeLauncher,
+	"cartridges":   PickupPistol,
+	"health":       PickupHealth,
+	"healthboost":  PickupBoost,
+	"greenarmour":  PickupGreenArmour,
+	"yellowarmour": PickupYellowArmor,
+	"quaddamage":   PickupQuadDamage,
+}
+
+// ParsePickup returns the pickup with the given name, as the game calls its
+// items ("shells", "health", "quaddamage", ...).
+func ParsePickup(name string) (ID, bool) {
+	id, ok := pickupNames[name]
+	return id, ok
+}
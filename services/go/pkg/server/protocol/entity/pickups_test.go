@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePickup(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		id   ID
+		ok   bool
+	}{
+		{name: "shells", id: PickupShotgun, ok: true},
+		{name: "riflerounds", id: PickupRifle, ok: true},
+		{name: "cartridges", id: PickupPistol, ok: true},
+		{name: "healthboost", id: PickupBoost, ok: true},
+		{name: "yellowarmour", id: PickupYellowArmor, ok: true},
+		{name: "quaddamage", id: PickupQuadDamage, ok: true},
+		{name: "yellowarmor"},
+		{name: "Health"},
+		{name: ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			id, ok := ParsePickup(test.name)
+			assert.Equal(t, test.ok, ok)
+			if ok {
+				assert.Equal(t, test.id, id)
+				_, isPickup := Pickups[id]
+				assert.True(t, isPickup)
+			}
+		})
+	}
+}
@@ -144,7 +144,11 @@ func Valid(gm ID) bool {
 	switch gm {
 	case FFA, CoopEdit, Insta, Effic, Tactics,
 		Teamplay, InstaTeam, EfficTeam, TacticsTeam,
-		CTF, InstaCTF, EfficCTF:
+		Capture, RegenCapture,
+		CTF, InstaCTF, EfficCTF,
+		Protect, InstaProtect, EfficProtect,
+		Hold, InstaHold, EfficHold,
+		Collect, InstaCollect, EfficCollect:
 		return true
 	default:
 		return false
@@ -10,6 +10,8 @@ const (
 	Veto
 	Locked
 	Private
+	// only used in server info replies, for servers that need a password
+	Password
 )
 
 func (mm ID) String() string {
@@ -24,6 +26,8 @@ func (mm ID) String() string {
 		return "locked"
 	case Private:
 		return "private"
+	case Password:
+		return "password"
 	default:
 		return strconv.Itoa(int(mm))
 	}
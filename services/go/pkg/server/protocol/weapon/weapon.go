@@ -2,6 +2,7 @@ package weapon
 
 import (
 	"math/rand"
+	"strconv"
 
 	"github.com/cfoust/sour/pkg/server/protocol/sound"
 )
@@ -19,6 +20,37 @@ const (
 	numWeapons int32 = iota
 )
 
+func (id ID) String() string {
+	switch id {
+	case Saw:
+		return "chainsaw"
+	case Shotgun:
+		return "shotgun"
+	case Minigun:
+		return "chaingun"
+	case RocketLauncher:
+		return "rocketlauncher"
+	case Rifle:
+		return "rifle"
+	case GrenadeLauncher:
+		return "grenadelauncher"
+	case Pistol:
+		return "pistol"
+	default:
+		return strconv.Itoa(int(id))
+	}
+}
+
+// Parse returns the weapon with the given name, as returned by String.
+func Parse(name string) (ID, bool) {
+	for id := Saw; id <= Pistol; id++ {
+		if id.String() == name {
+			return id, true
+		}
+	}
+	return Saw, false
+}
+
 var WeaponsWithAmmo = []ID{
 	Shotgun,
 	Minigun,
@@ -118,6 +150,18 @@ func SpawnAmmoFFA() (map[ID]int32, Weapon) {
 	}, byID[Pistol]
 }
 
+// SpawnAmmoGunGame hands out nothing but plenty of ammo for id (and the
+// chainsaw, which everyone has).
+func SpawnAmmoGunGame(id ID) (map[ID]int32, Weapon) {
+	ammo := map[ID]int32{
+		Saw: 1,
+	}
+	if id != Saw {
+		ammo[id] = byID[id].AmmoPickUpSize * 10
+	}
+	return ammo, byID[id]
+}
+
 // Flattens m into a slice
 func FlattenAmmo(m map[ID]int32) (values []int32) {
 	values = make([]int32, len(WeaponsWithAmmo))
@@ -132,4 +176,5 @@ func FlattenAmmo(m map[ID]int32) (values []int32) {
 const (
 	ExplosionDistanceScale   = 1.5
 	ExplosionSelfDamageScale = 0.5
+	QuadDamageScale          = 4
 )
@@ -0,0 +1,53 @@
+package weapon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		id   ID
+		ok   bool
+	}{
+		{name: "chainsaw", id: Saw, ok: true},
+		{name: "shotgun", id: Shotgun, ok: true},
+		{name: "chaingun", id: Minigun, ok: true},
+		{name: "rocketlauncher", id: RocketLauncher, ok: true},
+		{name: "rifle", id: Rifle, ok: true},
+		{name: "grenadelauncher", id: GrenadeLauncher, ok: true},
+		{name: "pistol", id: Pistol, ok: true},
+		{name: "minigun"},
+		{name: "Rifle"},
+		{name: "0"},
+		{name: ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			id, ok := Parse(test.name)
+			assert.Equal(t, test.ok, ok)
+			if ok {
+				assert.Equal(t, test.id, id)
+				assert.Equal(t, test.name, id.String())
+			}
+		})
+	}
+}
+
+func TestSpawnAmmoGunGame(t *testing.T) {
+	for _, test := range []struct {
+		id   ID
+		ammo map[ID]int32
+	}{
+		{id: Saw, ammo: map[ID]int32{Saw: 1}},
+		{id: Rifle, ammo: map[ID]int32{Saw: 1, Rifle: 50}},
+		{id: Shotgun, ammo: map[ID]int32{Saw: 1, Shotgun: 100}},
+	} {
+		t.Run(test.id.String(), func(t *testing.T) {
+			ammo, selected := SpawnAmmoGunGame(test.id)
+			assert.Equal(t, test.ammo, ammo)
+			assert.Equal(t, test.id, selected.ID)
+		})
+	}
+}
@@ -24,6 +24,12 @@ type Relay struct {
 	clientPackets          map[uint32][]protocol.Message
 
 	send map[uint32]sendFunc
+
+	// bots are run by the server, so they don't receive anything
+	bots map[uint32]struct{}
+
+	// receives all updates, regardless of who sent them
+	observer sendFunc
 }
 
 func New() *Relay {
@@ -37,6 +43,7 @@ func New() *Relay {
 		clientPackets:          map[uint32][]protocol.Message{},
 
 		send: map[uint32]sendFunc{},
+		bots: map[uint32]struct{}{},
 	}
 
 	go r.loop()
@@ -105,11 +112,42 @@ func (r *Relay) AddClient(cn uint32, sf sendFunc) (positions *Publisher, packets
 	return
 }
 
+// AddBot registers a bot. Updates published by the bot are sent to all
+// clients.
+func (r *Relay) AddBot(cn uint32) (positions *Publisher, packets *Publisher) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.bots[cn]; ok {
+		return nil, nil
+	}
+
+	r.bots[cn] = struct{}{}
+
+	positions, posCh := newPublisher(cn, r.incPositionsNotifs)
+	r.incPositions[cn] = posCh
+
+	packets, pktCh := newPublisher(cn, r.incClientPacketsNotifs)
+	r.incClientPackets[cn] = pktCh
+
+	return
+}
+
+// Observe registers a function that receives every update relayed, for
+// example to record demos. Pass nil to stop observing.
+func (r *Relay) Observe(sf sendFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.observer = sf
+}
+
 func (r *Relay) RemoveClient(cn uint32) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, ok := r.send[cn]; !ok {
+	_, isBot := r.bots[cn]
+	if _, ok := r.send[cn]; !ok && !isBot {
 		return errors.New("no such client")
 	}
 
@@ -118,6 +156,7 @@ func (r *Relay) RemoveClient(cn uint32) error {
 	delete(r.incClientPackets, cn)
 	delete(r.clientPackets, cn)
 	delete(r.send, cn)
+	delete(r.bots, cn)
 
 	return nil
 }
@@ -127,6 +166,9 @@ func (r *Relay) FlushPositionAndSend(cn uint32, p protocol.Message) {
 	defer r.mutex.Unlock()
 
 	if pos := r.positions[cn]; pos != nil {
+		if r.observer != nil {
+			r.observer(0, pos)
+		}
 		for _cn, send := range r.send {
 			if _cn == cn {
 				continue
@@ -136,6 +178,9 @@ func (r *Relay) FlushPositionAndSend(cn uint32, p protocol.Message) {
 		delete(r.positions, cn)
 	}
 
+	if r.observer != nil {
+		r.observer(0, []protocol.Message{p})
+	}
 	for _cn, send := range r.send {
 		if _cn == cn {
 			continue
@@ -164,7 +209,7 @@ func (r *Relay) flush(packets map[uint32][]protocol.Message, prefix func(uint32,
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if len(packets) == 0 || len(r.send) < 2 {
+	if len(packets) == 0 || len(r.send) < 2 && len(r.bots) == 0 && r.observer == nil {
 		return
 	}
 
@@ -183,10 +228,24 @@ func (r *Relay) flush(packets map[uint32][]protocol.Message, prefix func(uint32,
 		combined = append(combined, pkt...)
 	}
 
+	// the updates of bots come last, so no client skips them below
+	for cn := range r.bots {
+		pkt := packets[cn]
+		if pkt == nil {
+			continue
+		}
+		combined = append(combined, prefix(cn, pkt)...)
+		combined = append(combined, pkt...)
+	}
+
 	if len(combined) == 0 {
 		return
 	}
 
+	if r.observer != nil {
+		r.observer(channel, combined)
+	}
+
 	combined = append(combined, combined...)
 
 	offset := 0
@@ -194,6 +253,9 @@ func (r *Relay) flush(packets map[uint32][]protocol.Message, prefix func(uint32,
 		l := lengths[cn]
 		offset += l
 		p := combined[offset : (len(combined)/2)-l+offset]
+		if len(p) == 0 {
+			continue
+		}
 		r.send[cn](channel, p)
 	}
 
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"log"
+
+	C "github.com/cfoust/sour/pkg/game/constants"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+
+	"github.com/repeale/fp-go/option"
+)
+
+// After each match, the server moves on to the next entry of its Rotation
+// that suits the number of players, either in order or at random, skipping
+// maps that were played recently. Without a rotation, it keeps the mode and
+// picks one of Maps at random.
+
+// how many played maps we remember
+const maxRecentMaps = 32
+
+func (s *Server) rememberMap(map_ string) {
+	if map_ == "" {
+		return
+	}
+	s.recentMaps = append(s.recentMaps, map_)
+	if len(s.recentMaps) > maxRecentMaps {
+		s.recentMaps = s.recentMaps[len(s.recentMaps)-maxRecentMaps:]
+	}
+}
+
+// Whether map_ is one of the last RotationHistory maps that were played.
+func (s *Server) playedRecently(map_ string) bool {
+	n := s.RotationHistory
+	if n > len(s.recentMaps) {
+		n = len(s.recentMaps)
+	}
+	for _, recent := range s.recentMaps[len(s.recentMaps)-n:] {
+		if recent == map_ {
+			return true
+		}
+	}
+	return false
+}
+
+func (e RotationEntry) fits(players int) bool {
+	return players >= e.MinPlayers && (e.MaxPlayers == 0 || players <= e.MaxPlayers)
+}
+
+func (s *Server) rotationMode(e RotationEntry) gamemode.ID {
+	if e.Mode == "" {
+		return s.GameMode.ID()
+	}
+	mode := C.GetModeNumber(e.Mode)
+	if opt.IsNone(mode) {
+		log.Printf("invalid mode %q in map rotation", e.Mode)
+		return s.GameMode.ID()
+	}
+	return gamemode.ID(mode.Value)
+}
+
+// Returns the indices of the rotation entries to choose from.
+func (s *Server) rotationCandidates() []int {
+	players := s.NumberOfPlayers()
+
+	fitting := []int{}
+	for i, entry := range s.Rotation {
+		if entry.fits(players) {
+			fitting = append(fitting, i)
+		}
+	}
+	// better to play something that's too big or small than nothing
+	if len(fitting) == 0 {
+		for i := range s.Rotation {
+			fitting = append(fitting, i)
+		}
+	}
+
+	fresh := []int{}
+	for _, i := range fitting {
+		if !s.playedRecently(s.Rotation[i].Map) {
+			fresh = append(fresh, i)
+		}
+	}
+	if len(fresh) == 0 {
+		return fitting
+	}
+	return fresh
+}
+
+// Decides what to play after the current match.
+func (s *Server) nextInRotation() voteOption {
+	if len(s.Rotation) == 0 {
+		allMaps := []string{}
+		for _, map_ := range append(append([]string{}, s.Maps...), s.DefaultMap) {
+			if !s.playedRecently(map_) {
+				allMaps = append(allMaps, map_)
+			}
+		}
+		if len(allMaps) == 0 {
+			allMaps = append(allMaps, s.DefaultMap)
+		}
+		return voteOption{
+			mode: s.GameMode.ID(),
+			map_: allMaps[s.rng.Uint32()%uint32(len(allMaps))],
+		}
+	}
+
+	candidates := s.rotationCandidates()
+
+	next := candidates[0]
+	if s.ShuffleRotation {
+		next = candidates[s.rng.Uint32()%uint32(len(candidates))]
+	} else {
+		// the first candidate after the entry we played last
+		for _, i := range candidates {
+			if i >= s.rotationIndex {
+				next = i
+				break
+			}
+		}
+	}
+	s.rotationIndex = next + 1
+
+	entry := s.Rotation[next]
+	return voteOption{
+		mode: s.rotationMode(entry),
+		map_: entry.Map,
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextInRotation(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		rotation []RotationEntry
+		maps     []string
+		players  int
+		history  int
+		recent   []string
+		index    int
+		want     voteOption
+	}{
+		{
+			name:     "in order",
+			rotation: []RotationEntry{{Map: "complex"}, {Map: "turbine"}, {Map: "ot"}},
+			index:    1,
+			want:     voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+		{
+			name:     "wraps around",
+			rotation: []RotationEntry{{Map: "complex"}, {Map: "turbine"}, {Map: "ot"}},
+			index:    3,
+			want:     voteOption{mode: gamemode.Insta, map_: "complex"},
+		},
+		{
+			name: "too few players",
+			rotation: []RotationEntry{
+				{Map: "complex"}, {Map: "turbine", MinPlayers: 4}, {Map: "ot"},
+			},
+			players: 2,
+			index:   1,
+			want:    voteOption{mode: gamemode.Insta, map_: "ot"},
+		},
+		{
+			name: "too many players",
+			rotation: []RotationEntry{
+				{Map: "complex", MaxPlayers: 2}, {Map: "turbine"},
+			},
+			players: 3,
+			want:    voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+		{
+			name: "nothing fits",
+			rotation: []RotationEntry{
+				{Map: "complex", MinPlayers: 8}, {Map: "turbine", MinPlayers: 8},
+			},
+			players: 2,
+			index:   1,
+			want:    voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+		{
+			name:     "played recently",
+			rotation: []RotationEntry{{Map: "complex"}, {Map: "turbine"}, {Map: "ot"}},
+			history:  2,
+			recent:   []string{"turbine"},
+			index:    1,
+			want:     voteOption{mode: gamemode.Insta, map_: "ot"},
+		},
+		{
+			name:     "played before the history",
+			rotation: []RotationEntry{{Map: "complex"}, {Map: "turbine"}, {Map: "ot"}},
+			history:  1,
+			recent:   []string{"turbine", "complex"},
+			index:    1,
+			want:     voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+		{
+			name:     "everything played recently",
+			rotation: []RotationEntry{{Map: "complex"}, {Map: "turbine"}, {Map: "ot"}},
+			history:  3,
+			recent:   []string{"complex", "turbine", "ot"},
+			index:    1,
+			want:     voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+		{
+			name:     "mode",
+			rotation: []RotationEntry{{Mode: "instactf", Map: "forge"}},
+			want:     voteOption{mode: gamemode.InstaCTF, map_: "forge"},
+		},
+		{
+			name:     "invalid mode",
+			rotation: []RotationEntry{{Mode: "instagib", Map: "forge"}},
+			want:     voteOption{mode: gamemode.Insta, map_: "forge"},
+		},
+		{
+			name:    "no rotation",
+			maps:    []string{"complex"},
+			history: 1,
+			recent:  []string{"complex"},
+			want:    voteOption{mode: gamemode.Insta, map_: "turbine"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			clients := &ClientManager{}
+			for i := 0; i < test.players; i++ {
+				c := &Client{Joined: true}
+				c.State = playerstate.Dead
+				clients.clients = append(clients.clients, c)
+			}
+
+			s := &Server{
+				Config: &Config{
+					DefaultMap:      "turbine",
+					Maps:            test.maps,
+					Rotation:        test.rotation,
+					RotationHistory: test.history,
+				},
+				State:         &State{GameMode: game.NewInsta(nil)},
+				Clients:       clients,
+				rng:           rand.New(rand.NewSource(0)),
+				rotationIndex: test.index,
+				recentMaps:    test.recent,
+			}
+			assert.Equal(t, test.want, s.nextInRotation())
+		})
+	}
+}
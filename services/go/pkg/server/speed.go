@@ -0,0 +1,49 @@
+package server
+
+import (
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+)
+
+// values taken from server.cpp in the vanilla game
+const (
+	defaultGameSpeed = 100
+	minGameSpeed     = 10
+	maxGameSpeed     = 1000
+)
+
+func clampGameSpeed(speed int32) int32 {
+	if speed < minGameSpeed {
+		return minGameSpeed
+	}
+	if speed > maxGameSpeed {
+		return maxGameSpeed
+	}
+	return speed
+}
+
+// SetGameSpeed changes how fast the game runs, in percent. All game timers
+// (the match clock, item respawns, flag resets etc.) are scaled accordingly.
+// c may be nil when the speed is changed by the server itself.
+func (s *Server) SetGameSpeed(c *Client, speed int32) {
+	if c != nil && c.Role == role.None {
+		c.Message(cubecode.Fail("you can't do that"))
+		return
+	}
+
+	speed = clampGameSpeed(speed)
+	if speed == s.Speed {
+		return
+	}
+
+	s.Speed = speed
+	s.Clock.SetSpeed(speed)
+
+	var cn int32 = -1
+	if c != nil {
+		cn = int32(c.CN)
+	}
+	// clients print the new speed themselves
+	s.Broadcast(P.GameSpeed{Speed: speed, Client: cn})
+}
@@ -8,10 +8,15 @@ import (
 )
 
 type State struct {
-	Clock      game.Clock
-	MasterMode mastermode.ID
-	GameMode   game.Mode
-	Map        string
-	UpSince    time.Time
-	NumClients func() int // number of clients connected
+	Clock       game.Clock
+	MasterMode  mastermode.ID
+	GameMode    game.Mode
+	Map         string
+	UpSince     time.Time
+	NumClients  func() int // number of clients connected
+	BotLimit    int
+	BotBalance  bool
+	RecordDemos bool
+	// game speed in percent of real time
+	Speed int32
 }
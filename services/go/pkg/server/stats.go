@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/cfoust/sour/pkg/server/protocol/playerstate"
+)
+
+// At intermission, the server sums up how everyone did in a MatchReport and
+// publishes it on MatchReports if ReportStats is enabled.
+
+type WeaponReport struct {
+	Shots  int32 `json:"shots"`
+	Hits   int32 `json:"hits"`
+	Damage int32 `json:"damage"`
+	// in percent of the damage the shots could have done
+	Accuracy int32 `json:"accuracy"`
+}
+
+type PlayerReport struct {
+	// who the player was on the server, the cluster knows the rest
+	SessionID uint32 `json:"-"`
+	// the account the player was logged in with, 0 if none
+	Account uint   `json:"-"`
+	Name    string `json:"name"`
+	Team    string `json:"team,omitempty"`
+	Bot     bool   `json:"bot,omitempty"`
+	// whether the player left before the match ended
+	Left bool `json:"left,omitempty"`
+
+	Frags         int32 `json:"frags"`
+	Deaths        int32 `json:"deaths"`
+	Teamkills     int32 `json:"teamkills"`
+	Suicides      int32 `json:"suicides"`
+	LongestStreak int32 `json:"longestStreak"`
+
+	Flags         int32 `json:"flags"`
+	FlagsTaken    int32 `json:"flagsTaken"`
+	FlagsReturned int32 `json:"flagsReturned"`
+	Pickups       int32 `json:"pickups"`
+
+	DamageDealt     int32 `json:"damageDealt"`
+	DamageReceived  int32 `json:"damageReceived"`
+	DamagePotential int32 `json:"damagePotential"`
+	Accuracy        int32 `json:"accuracy"`
+
+	Weapons map[string]WeaponReport `json:"weapons"`
+}
+
+type MatchReport struct {
+	Map     string    `json:"map"`
+	Mode    string    `json:"mode"`
+	Started time.Time `json:"started"`
+	Ended   time.Time `json:"ended"`
+	// team name → score, only in team modes
+	Teams   map[string]int32 `json:"teams,omitempty"`
+	Players []PlayerReport   `json:"players"`
+}
+
+func (r *MatchReport) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// like vanilla, accuracy is the share of the potential damage that was dealt
+func accuracy(damage, potential int32) int32 {
+	if potential <= 0 {
+		return 0
+	}
+	return damage * 100 / potential
+}
+
+// Returns the account c is logged in with. Players that left might not be
+// known to the owner of the server anymore, so we remember the account they
+// had.
+func (s *Server) account(c *Client) uint {
+	if s.Account == nil || c.IsBot() {
+		return c.Account
+	}
+	if account, ok := s.Account(c.SessionID); ok {
+		c.Account = account
+	}
+	return c.Account
+}
+
+func (s *Server) playerReport(c *Client) PlayerReport {
+	report := PlayerReport{
+		SessionID:       c.SessionID,
+		Account:         s.account(c),
+		Name:            c.Name,
+		Bot:             c.IsBot(),
+		Frags:           c.Frags,
+		Deaths:          c.Deaths,
+		Teamkills:       c.Teamkills,
+		Suicides:        c.Stats.Suicides,
+		LongestStreak:   c.Stats.LongestStreak,
+		Flags:           c.Flags,
+		FlagsTaken:      c.Stats.FlagsTaken,
+		FlagsReturned:   c.Stats.FlagsReturned,
+		Pickups:         c.Stats.Pickups,
+		DamageDealt:     c.Damage,
+		DamageReceived:  c.Stats.DamageReceived,
+		DamagePotential: c.DamagePotential,
+		Accuracy:        accuracy(c.Damage, c.DamagePotential),
+		Weapons:         map[string]WeaponReport{},
+	}
+
+	if c.Team != game.NoTeam {
+		report.Team = c.Team.Name
+	}
+
+	for id, stats := range c.Stats.Weapons {
+		report.Weapons[id.String()] = WeaponReport{
+			Shots:    stats.Shots,
+			Hits:     stats.Hits,
+			Damage:   stats.Damage,
+			Accuracy: accuracy(stats.Damage, stats.DamagePotential),
+		}
+	}
+
+	return report
+}
+
+// Remembers how a player that leaves in the middle of a match did so far.
+func (s *Server) saveDepartedStats(c *Client) {
+	if !c.Joined || s.matchStarted.IsZero() {
+		return
+	}
+
+	report := s.playerReport(c)
+	report.Left = true
+	s.departed = append(s.departed, report)
+}
+
+func (s *Server) matchReport() *MatchReport {
+	report := MatchReport{
+		Map:     s.Map,
+		Mode:    s.GameMode.ID().String(),
+		Started: s.matchStarted,
+		Ended:   time.Now(),
+		Players: append([]PlayerReport{}, s.departed...),
+	}
+
+	if teamedMode, ok := s.GameMode.(game.TeamMode); ok {
+		report.Teams = map[string]int32{}
+		teamedMode.ForEachTeam(func(t *game.Team) {
+			report.Teams[t.Name] = t.Score
+		})
+	}
+
+	s.Clients.ForEach(func(c *Client) {
+		// spectators didn't play
+		if !c.Joined || (c.State == playerstate.Spectator && c.Deaths == 0 && c.DamagePotential == 0) {
+			return
+		}
+		report.Players = append(report.Players, s.playerReport(c))
+	})
+
+	return &report
+}
+
+// Starts collecting stats for a new match.
+func (s *Server) resetStats() {
+	s.matchStarted = time.Now()
+	s.departed = nil
+}
+
+// Publishes the stats of the match that just ended.
+func (s *Server) reportStats() {
+	if !s.ReportStats || s.matchStarted.IsZero() || s.GameMode.ID() == gamemode.CoopEdit {
+		return
+	}
+
+	s.MatchReports.Publish(s.matchReport())
+	s.matchStarted = time.Time{}
+}
@@ -15,6 +15,9 @@ const (
 // The Timer type represents a single event. When the Timer expires,
 // the current time will be sent on C, unless the Timer was created by AfterFunc.
 // A Timer must be created with NewTimer or AfterFunc.
+//
+// Durations are measured in game time, which passes faster or slower than
+// real time depending on the speed set with SetSpeed.
 type Timer struct {
 	t  *time.Timer
 	C  <-chan time.Time
@@ -24,6 +27,7 @@ type Timer struct {
 	state     int
 	duration  time.Duration
 	startedAt time.Time
+	speed     int32 // in percent of real time
 }
 
 // AfterFunc waits after calling its Start method for the duration
@@ -34,6 +38,7 @@ func AfterFunc(d time.Duration, f func()) *Timer {
 	t := &Timer{
 		duration: d,
 		l:        new(deadlock.Mutex),
+		speed:    100,
 	}
 	t.fn = func() {
 		t.state = stateExpired
@@ -51,6 +56,7 @@ func NewTimer(d time.Duration) *Timer {
 		C:        c,
 		duration: d,
 		l:        new(deadlock.Mutex),
+		speed:    100,
 	}
 	t.fn = func() {
 		t.state = stateExpired
@@ -68,10 +74,20 @@ func (t *Timer) Start() bool {
 	}
 	t.startedAt = time.Now()
 	t.state = stateActive
-	t.t = time.AfterFunc(t.duration, t.fn)
+	t.t = time.AfterFunc(t.realDuration(t.duration), t.fn)
 	return true
 }
 
+// converts game time to real time
+func (t *Timer) realDuration(d time.Duration) time.Duration {
+	return d * 100 / time.Duration(t.speed)
+}
+
+// returns the game time that passed since the timer was (re)started
+func (t *Timer) elapsed() time.Duration {
+	return time.Now().Sub(t.startedAt) * time.Duration(t.speed) / 100
+}
+
 // Pause pauses current timer until Start method will be called.
 // Next Start call will wait rest of duration.
 func (t *Timer) Pause() bool {
@@ -85,7 +101,7 @@ func (t *Timer) Pause() bool {
 		return false
 	}
 	t.state = stateIdle
-	t.duration -= time.Now().Sub(t.startedAt)
+	t.duration -= t.elapsed()
 	return true
 }
 
@@ -110,11 +126,34 @@ func (t *Timer) SetTimeLeft(d time.Duration) bool {
 	t.duration = d
 	if t.state == stateActive {
 		t.startedAt = time.Now()
-		t.t = time.AfterFunc(d, t.fn)
+		t.t = time.AfterFunc(t.realDuration(d), t.fn)
 	}
 	return true
 }
 
+// SetSpeed changes how fast game time passes for this timer, in percent of
+// real time. The game time left is kept.
+func (t *Timer) SetSpeed(speed int32) {
+	if speed <= 0 {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.state == stateActive {
+		if !t.t.Stop() {
+			t.state = stateExpired
+			return
+		}
+		t.duration -= t.elapsed()
+		t.startedAt = time.Now()
+		t.speed = speed
+		t.t = time.AfterFunc(t.realDuration(t.duration), t.fn)
+		return
+	}
+	t.speed = speed
+}
+
 // Stop prevents the Timer from firing. It returns true if the call stops the timer,
 // false if the timer has already expired or been stopped.
 // Stop does not close the channel, to prevent a read from the channel succeeding incorrectly.
@@ -142,7 +181,7 @@ func (t *Timer) TimeLeft() time.Duration {
 	case stateIdle:
 		return t.duration
 	case stateActive:
-		return t.duration - time.Now().Sub(t.startedAt)
+		return t.duration - t.elapsed()
 	case stateExpired:
 		return 0
 	default:
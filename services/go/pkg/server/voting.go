@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/protocol/cubecode"
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+)
+
+// Unless the server is in veto mode or above, where masters decide, players
+// vote for the next map and mode with the game's map selection. The first
+// vote opens a vote window: as soon as one choice has the votes of more than
+// VoteMajority percent of the players (or all of them) it is played,
+// otherwise the choice with the most votes wins once the window closes.
+// During intermission, the votes decide which map comes next.
+
+const (
+	defaultVoteMajority = 50
+	defaultVoteDuration = 30 * time.Second
+)
+
+type voteOption struct {
+	mode gamemode.ID
+	map_ string
+}
+
+func (o voteOption) String() string {
+	return fmt.Sprintf("%s on %s", o.mode, o.map_)
+}
+
+type mapVote struct {
+	// session ID → what they voted for
+	ballots map[uint32]voteOption
+	// in the order they were first voted for, which breaks ties
+	options []voteOption
+	timer   *time.Timer
+}
+
+func (v *mapVote) count(option voteOption) (n int) {
+	for _, ballot := range v.ballots {
+		if ballot == option {
+			n++
+		}
+	}
+	return
+}
+
+// Returns the option with the most votes, if any.
+func (v *mapVote) leader() (option voteOption, ok bool) {
+	most := 0
+	for _, candidate := range v.options {
+		if n := v.count(candidate); n > most {
+			option, most = candidate, n
+		}
+	}
+	return option, most > 0
+}
+
+func (s *Server) voteMajority() int {
+	if s.VoteMajority <= 0 || s.VoteMajority > 100 {
+		return defaultVoteMajority
+	}
+	return s.VoteMajority
+}
+
+func (s *Server) voteDuration() time.Duration {
+	if s.VoteDuration <= 0 {
+		return defaultVoteDuration
+	}
+	return time.Duration(s.VoteDuration) * time.Second
+}
+
+// How many of the voters an option needs to win right away with the given
+// majority in percent. Even with a majority of 100, everyone is enough.
+func votesNeeded(voters, majority int) int {
+	needed := voters*majority/100 + 1
+	if needed > voters {
+		needed = voters
+	}
+	if needed < 1 {
+		needed = 1
+	}
+	return needed
+}
+
+func (s *Server) votesNeeded() int {
+	voters := 0
+	s.Clients.ForEach(func(c *Client) {
+		if c.Joined && !c.IsBot() {
+			voters++
+		}
+	})
+	return votesNeeded(voters, s.voteMajority())
+}
+
+// Vote records c's vote for playing mode on map_.
+func (s *Server) Vote(c *Client, mode gamemode.ID, map_ string) {
+	if s.vote == nil {
+		vote := &mapVote{
+			ballots: map[uint32]voteOption{},
+		}
+		// the vote is closed in the server's loop, like everything else
+		// that changes it
+		vote.timer = time.AfterFunc(s.voteDuration(), func() {
+			select {
+			case s.voteTimeouts <- vote:
+			case <-s.Ctx().Done():
+			}
+		})
+		s.vote = vote
+	}
+
+	option := voteOption{mode: mode, map_: map_}
+	if previous, ok := s.vote.ballots[c.SessionID]; ok && previous == option {
+		return
+	}
+	s.vote.ballots[c.SessionID] = option
+
+	known := false
+	for _, other := range s.vote.options {
+		if other == option {
+			known = true
+			break
+		}
+	}
+	if !known {
+		s.vote.options = append(s.vote.options, option)
+	}
+
+	votes, needed := s.vote.count(option), s.votesNeeded()
+	s.Message(fmt.Sprintf("%s voted for %s (%d/%d votes)", s.Clients.UniqueName(c), cubecode.Green(option.String()), votes, needed))
+
+	if votes >= needed {
+		s.Message(fmt.Sprintf("vote passed: %s", option))
+		s.StartGame(s.StartMode(option.mode), option.map_)
+	}
+}
+
+// Drops the vote of a player that left.
+func (s *Server) withdrawVote(c *Client) {
+	if s.vote != nil {
+		delete(s.vote.ballots, c.SessionID)
+	}
+}
+
+// Called when the window of the given vote closes.
+func (s *Server) closeVote(vote *mapVote) {
+	// the vote might have ended in the meantime
+	if s.vote == nil || s.vote != vote {
+		return
+	}
+
+	// during intermission, the map change picks up the votes
+	if s.pendingMapChange != nil {
+		return
+	}
+
+	option, ok := s.vote.leader()
+	s.resetVote()
+	if !ok {
+		return
+	}
+
+	s.Message(fmt.Sprintf("vote passed: %s", option))
+	s.StartGame(s.StartMode(option.mode), option.map_)
+}
+
+// Returns the option that won the current vote, if there was one.
+func (s *Server) voteWinner() (voteOption, bool) {
+	if s.vote == nil {
+		return voteOption{}, false
+	}
+	return s.vote.leader()
+}
+
+func (s *Server) resetVote() {
+	if s.vote == nil {
+		return
+	}
+	s.vote.timer.Stop()
+	s.vote = nil
+}
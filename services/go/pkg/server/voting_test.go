@@ -0,0 +1,78 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cfoust/sour/pkg/server/protocol/gamemode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVotesNeeded(t *testing.T) {
+	for _, test := range []struct {
+		voters, majority int
+		needed           int
+	}{
+		{voters: 0, majority: 50, needed: 1},
+		{voters: 1, majority: 50, needed: 1},
+		{voters: 2, majority: 50, needed: 2},
+		{voters: 3, majority: 50, needed: 2},
+		{voters: 4, majority: 50, needed: 3},
+		{voters: 10, majority: 75, needed: 8},
+		{voters: 3, majority: 100, needed: 3},
+		{voters: 10, majority: 0, needed: 1},
+	} {
+		assert.Equal(t, test.needed, votesNeeded(test.voters, test.majority),
+			"%d voters with a majority of %d%%", test.voters, test.majority)
+	}
+}
+
+func TestVoteLeader(t *testing.T) {
+	onComplex := voteOption{mode: gamemode.Insta, map_: "complex"}
+	onTurbine := voteOption{mode: gamemode.Insta, map_: "turbine"}
+	ctfOnTurbine := voteOption{mode: gamemode.InstaCTF, map_: "turbine"}
+
+	for _, test := range []struct {
+		name    string
+		ballots map[uint32]voteOption
+		options []voteOption
+		leader  voteOption
+		ok      bool
+	}{
+		{name: "no votes"},
+		{
+			name:    "one vote",
+			ballots: map[uint32]voteOption{1: onTurbine},
+			options: []voteOption{onTurbine},
+			leader:  onTurbine,
+			ok:      true,
+		},
+		{
+			name:    "most votes",
+			ballots: map[uint32]voteOption{1: onComplex, 2: onTurbine, 3: onTurbine},
+			options: []voteOption{onComplex, onTurbine},
+			leader:  onTurbine,
+			ok:      true,
+		},
+		{
+			name:    "tie goes to the first option",
+			ballots: map[uint32]voteOption{1: ctfOnTurbine, 2: onTurbine},
+			options: []voteOption{ctfOnTurbine, onTurbine},
+			leader:  ctfOnTurbine,
+			ok:      true,
+		},
+		{
+			name:    "withdrawn votes",
+			ballots: map[uint32]voteOption{2: onTurbine},
+			options: []voteOption{onComplex, onTurbine},
+			leader:  onTurbine,
+			ok:      true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			vote := &mapVote{ballots: test.ballots, options: test.options}
+			leader, ok := vote.leader()
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.leader, leader)
+		})
+	}
+}
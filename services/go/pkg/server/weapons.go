@@ -0,0 +1,168 @@
+package server
+
+import (
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/armour"
+	"github.com/cfoust/sour/pkg/server/protocol/entity"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Servers can change the stock weapons, what players spawn with and how long
+// items take to respawn. Clients simulate some of this themselves: they
+// always fire the stock number of rays, never report hits beyond the stock
+// range and add the stock amounts when they pick something up. Settings
+// that would make the server disagree with them are ignored.
+
+type rules struct {
+	weapons map[weapon.ID]weapon.Weapon
+	// ammo players spawn with, negative for none
+	ammo           map[weapon.ID]int32
+	health         int32
+	armour         int32
+	spawnWeapon    weapon.ID
+	hasSpawnWeapon bool
+	respawn        map[entity.ID]time.Duration
+}
+
+func parseRules(conf *Config) *rules {
+	r := &rules{
+		weapons: map[weapon.ID]weapon.Weapon{},
+		ammo:    map[weapon.ID]int32{},
+		respawn: map[entity.ID]time.Duration{},
+	}
+
+	for _, wc := range conf.Weapons {
+		id, ok := weapon.Parse(wc.Weapon)
+		if !ok {
+			log.Warn().Msgf("unknown weapon %q in weapon config", wc.Weapon)
+			continue
+		}
+
+		stock := weapon.ByID(id)
+		wpn := stock
+		switch {
+		case wc.Damage < 0:
+			log.Warn().Msgf("%s can't do negative damage", id)
+		case wc.Damage > 0:
+			wpn.Damage = int32(wc.Damage)
+		}
+		switch {
+		case wc.Rays < 0 || int32(wc.Rays) > stock.Rays:
+			log.Warn().Msgf("%s hits with 1 to %d rays, not %d", id, stock.Rays, wc.Rays)
+		case wc.Rays > 0:
+			wpn.Rays = int32(wc.Rays)
+		}
+		switch {
+		case wc.Range < 0 || wc.Range > stock.Range:
+			log.Warn().Msgf("%s has a range of at most %.0f, not %.0f", id, stock.Range, wc.Range)
+		case wc.Range > 0:
+			wpn.Range = wc.Range
+		}
+		r.weapons[id] = wpn
+
+		switch {
+		case wc.Ammo == 0:
+		case id == weapon.Saw:
+			log.Warn().Msg("the chainsaw doesn't use ammo")
+		default:
+			r.ammo[id] = int32(wc.Ammo)
+		}
+	}
+
+	if conf.SpawnHealth < 0 || conf.SpawnArmour < 0 {
+		log.Warn().Msg("players can't spawn with negative health or armour")
+	}
+	if conf.SpawnHealth > 0 {
+		r.health = int32(conf.SpawnHealth)
+	}
+	if conf.SpawnArmour > 0 {
+		r.armour = int32(conf.SpawnArmour)
+	}
+
+	if conf.SpawnWeapon != "" {
+		r.spawnWeapon, r.hasSpawnWeapon = weapon.Parse(conf.SpawnWeapon)
+		if !r.hasSpawnWeapon {
+			log.Warn().Msgf("unknown spawn weapon %q", conf.SpawnWeapon)
+		}
+	}
+
+	for _, pr := range conf.PickupRespawn {
+		typ, ok := entity.ParsePickup(pr.Item)
+		if !ok {
+			log.Warn().Msgf("unknown item %q in pickup respawn times", pr.Item)
+			continue
+		}
+		if pr.Seconds <= 0 {
+			log.Warn().Msgf("%s must take at least a second to respawn", pr.Item)
+			continue
+		}
+		r.respawn[typ] = time.Duration(pr.Seconds) * time.Second
+	}
+
+	return r
+}
+
+func hasAmmo(ps *game.PlayerState, id weapon.ID) bool {
+	return id == weapon.Saw || ps.Ammo[id] > 0
+}
+
+// Changes what a player the mode just spawned starts out with. Modes that
+// hand out their own weapons keep them.
+func (r *rules) spawn(ps *game.PlayerState, keepWeapons bool) {
+	if r.health > 0 {
+		ps.Health = r.health
+		if ps.MaxHealth < r.health {
+			ps.MaxHealth = r.health
+		}
+	}
+	if r.armour > 0 {
+		ps.Armour = r.armour
+		if ps.ArmourType == armour.None {
+			ps.ArmourType = armour.Blue
+		}
+	}
+
+	if keepWeapons {
+		return
+	}
+
+	for id, amount := range r.ammo {
+		if amount < 0 {
+			amount = 0
+		}
+		ps.Ammo[id] = amount
+	}
+
+	if r.hasSpawnWeapon && hasAmmo(ps, r.spawnWeapon) {
+		ps.SelectedWeapon = weapon.ByID(r.spawnWeapon)
+	}
+	if hasAmmo(ps, ps.SelectedWeapon.ID) {
+		return
+	}
+	ps.SelectedWeapon = weapon.ByID(weapon.Saw)
+	for _, id := range weapon.WeaponsWithAmmo {
+		if hasAmmo(ps, id) {
+			ps.SelectedWeapon = weapon.ByID(id)
+			break
+		}
+	}
+}
+
+// Weapon returns the stats of a weapon on this server.
+func (s *Server) Weapon(id weapon.ID) weapon.Weapon {
+	if wpn, ok := s.rules.weapons[id]; ok {
+		return wpn
+	}
+	return weapon.ByID(id)
+}
+
+// PickupRespawn returns how long items of the given type take to respawn,
+// if the server changed it.
+func (s *Server) PickupRespawn(typ entity.ID) (time.Duration, bool) {
+	delay, ok := s.rules.respawn[typ]
+	return delay, ok
+}
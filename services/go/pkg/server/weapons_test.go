@@ -0,0 +1,184 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cfoust/sour/pkg/server/game"
+	"github.com/cfoust/sour/pkg/server/protocol/armour"
+	"github.com/cfoust/sour/pkg/server/protocol/entity"
+	"github.com/cfoust/sour/pkg/server/protocol/weapon"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the stock weapon with the changes made by change.
+func changedWeapon(id weapon.ID, change func(*weapon.Weapon)) weapon.Weapon {
+	wpn := weapon.ByID(id)
+	change(&wpn)
+	return wpn
+}
+
+func TestParseRules(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		conf    Config
+		weapons map[weapon.ID]weapon.Weapon
+		ammo    map[weapon.ID]int32
+		respawn map[entity.ID]time.Duration
+	}{
+		{
+			name: "changed weapon",
+			conf: Config{Weapons: []WeaponConfig{
+				{Weapon: "rifle", Damage: 150, Range: 1000, Ammo: 20},
+			}},
+			weapons: map[weapon.ID]weapon.Weapon{
+				weapon.Rifle: changedWeapon(weapon.Rifle, func(w *weapon.Weapon) {
+					w.Damage = 150
+					w.Range = 1000
+				}),
+			},
+			ammo: map[weapon.ID]int32{weapon.Rifle: 20},
+		},
+		{
+			name: "unknown weapon",
+			conf: Config{Weapons: []WeaponConfig{{Weapon: "bfg", Damage: 500}}},
+		},
+		{
+			name: "impossible stats",
+			conf: Config{Weapons: []WeaponConfig{
+				{Weapon: "shotgun", Damage: -1, Rays: 30, Range: 5000},
+			}},
+			weapons: map[weapon.ID]weapon.Weapon{
+				weapon.Shotgun: weapon.ByID(weapon.Shotgun),
+			},
+		},
+		{
+			name: "fewer rays",
+			conf: Config{Weapons: []WeaponConfig{{Weapon: "shotgun", Rays: 10}}},
+			weapons: map[weapon.ID]weapon.Weapon{
+				weapon.Shotgun: changedWeapon(weapon.Shotgun, func(w *weapon.Weapon) {
+					w.Rays = 10
+				}),
+			},
+		},
+		{
+			name: "ammo",
+			conf: Config{Weapons: []WeaponConfig{
+				{Weapon: "chainsaw", Ammo: 10},
+				{Weapon: "rocketlauncher", Ammo: -1},
+			}},
+			weapons: map[weapon.ID]weapon.Weapon{
+				weapon.Saw:            weapon.ByID(weapon.Saw),
+				weapon.RocketLauncher: weapon.ByID(weapon.RocketLauncher),
+			},
+			ammo: map[weapon.ID]int32{weapon.RocketLauncher: -1},
+		},
+		{
+			name: "pickup respawn",
+			conf: Config{PickupRespawn: []PickupRespawn{
+				{Item: "quaddamage", Seconds: 60},
+				{Item: "health", Seconds: 0},
+				{Item: "megahealth", Seconds: 10},
+			}},
+			respawn: map[entity.ID]time.Duration{
+				entity.PickupQuadDamage: time.Minute,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.weapons == nil {
+				test.weapons = map[weapon.ID]weapon.Weapon{}
+			}
+			if test.ammo == nil {
+				test.ammo = map[weapon.ID]int32{}
+			}
+			if test.respawn == nil {
+				test.respawn = map[entity.ID]time.Duration{}
+			}
+
+			r := parseRules(&test.conf)
+			assert.Equal(t, test.weapons, r.weapons)
+			assert.Equal(t, test.ammo, r.ammo)
+			assert.Equal(t, test.respawn, r.respawn)
+		})
+	}
+}
+
+func TestRulesSpawn(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		conf        Config
+		keepWeapons bool
+		health      int32
+		armour      int32
+		armourType  armour.ID
+		ammo        map[weapon.ID]int32
+		selected    weapon.ID
+	}{
+		{
+			name:       "mode's loadout",
+			health:     100,
+			armourType: armour.None,
+			ammo:       map[weapon.ID]int32{weapon.Saw: 1, weapon.Rifle: 100},
+			selected:   weapon.Rifle,
+		},
+		{
+			name:       "health and armour",
+			conf:       Config{SpawnHealth: 200, SpawnArmour: 50},
+			health:     200,
+			armour:     50,
+			armourType: armour.Blue,
+			ammo:       map[weapon.ID]int32{weapon.Saw: 1, weapon.Rifle: 100},
+			selected:   weapon.Rifle,
+		},
+		{
+			name: "spawn weapon",
+			conf: Config{
+				Weapons:     []WeaponConfig{{Weapon: "shotgun", Ammo: 10}},
+				SpawnWeapon: "shotgun",
+			},
+			health:     100,
+			armourType: armour.None,
+			ammo:       map[weapon.ID]int32{weapon.Saw: 1, weapon.Rifle: 100, weapon.Shotgun: 10},
+			selected:   weapon.Shotgun,
+		},
+		{
+			name: "spawn weapon without ammo",
+			conf: Config{
+				Weapons:     []WeaponConfig{{Weapon: "rifle", Ammo: -1}},
+				SpawnWeapon: "shotgun",
+			},
+			health:     100,
+			armourType: armour.None,
+			ammo:       map[weapon.ID]int32{weapon.Saw: 1, weapon.Rifle: 0},
+			selected:   weapon.Saw,
+		},
+		{
+			name: "modes that hand out weapons",
+			conf: Config{
+				Weapons:     []WeaponConfig{{Weapon: "shotgun", Ammo: 10}},
+				SpawnWeapon: "shotgun",
+				SpawnHealth: 150,
+			},
+			keepWeapons: true,
+			health:      150,
+			armourType:  armour.None,
+			ammo:        map[weapon.ID]int32{weapon.Saw: 1, weapon.Rifle: 100},
+			selected:    weapon.Rifle,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ps := game.NewPlayerState()
+			ps.Health, ps.MaxHealth = 100, 100
+			ps.ArmourType = armour.None
+			ps.Ammo, ps.SelectedWeapon = weapon.SpawnAmmoInsta()
+
+			parseRules(&test.conf).spawn(&ps, test.keepWeapons)
+			assert.Equal(t, test.health, ps.Health)
+			assert.Equal(t, test.armour, ps.Armour)
+			assert.Equal(t, test.armourType, ps.ArmourType)
+			assert.Equal(t, test.ammo, ps.Ammo)
+			assert.Equal(t, test.selected, ps.SelectedWeapon.ID)
+		})
+	}
+}
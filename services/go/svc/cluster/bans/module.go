@@ -0,0 +1,100 @@
+package bans
+
+import (
+	"context"
+	"time"
+
+	"github.com/cfoust/sour/svc/cluster/state"
+
+	"gorm.io/gorm"
+)
+
+// Bans keeps track of the hosts and users that may not connect to the
+// cluster.
+type Bans struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Bans {
+	return &Bans{
+		db: db,
+	}
+}
+
+func newBan(duration time.Duration, reason string, server string) state.Ban {
+	ban := state.Ban{
+		Created: time.Now(),
+		Reason:  reason,
+		Server:  server,
+	}
+	if duration > 0 {
+		ban.Expires = ban.Created.Add(duration)
+	}
+	return ban
+}
+
+// BanHost bans a host (i.e. the hash of an IP address). A duration of 0 bans
+// the host permanently. server is the ID of the server that issued the ban, if
+// any.
+func (b *Bans) BanHost(ctx context.Context, host *state.Host, duration time.Duration, reason string, server string) (*state.Ban, error) {
+	ban := newBan(duration, reason, server)
+	ban.HostID = host.ID
+
+	err := b.db.WithContext(ctx).Create(&ban).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+// BanUser bans a user regardless of where they connect from. A duration of 0
+// bans the user permanently.
+func (b *Bans) BanUser(ctx context.Context, user *state.User, duration time.Duration, reason string, server string) (*state.Ban, error) {
+	ban := newBan(duration, reason, server)
+	ban.UserID = user.ID
+
+	err := b.db.WithContext(ctx).Create(&ban).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+func (b *Bans) find(ctx context.Context, query *state.Ban) (*state.Ban, error) {
+	var bans []state.Ban
+	err := b.db.WithContext(ctx).Where(query).Find(&bans).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ban := range bans {
+		if ban.Active() {
+			return &ban, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindHostBan returns the ban currently in effect for the host, or nil if
+// there is none.
+func (b *Bans) FindHostBan(ctx context.Context, host *state.Host) (*state.Ban, error) {
+	return b.find(ctx, &state.Ban{HostID: host.ID})
+}
+
+// FindUserBan returns the ban currently in effect for the user, or nil if
+// there is none.
+func (b *Bans) FindUserBan(ctx context.Context, user *state.User) (*state.Ban, error) {
+	return b.find(ctx, &state.Ban{UserID: user.ID})
+}
+
+// ClearServer lifts the temporary bans issued by a server, like N_CLEARBANS
+// does in vanilla Sauerbraten.
+func (b *Bans) ClearServer(ctx context.Context, server string) error {
+	return b.db.WithContext(ctx).
+		Where("server = ? AND expires > ?", server, time.Time{}).
+		Delete(&state.Ban{}).
+		Error
+}
@@ -0,0 +1,98 @@
+package bans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cfoust/sour/svc/cluster/state"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBans(t *testing.T) *Bans {
+	db, err := state.InitDB(":memory:")
+	assert.NoError(t, err)
+	return New(db)
+}
+
+func TestFindBan(t *testing.T) {
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name     string
+		duration time.Duration
+		// how long ago the ban was issued
+		age    time.Duration
+		banned bool
+	}{
+		{name: "permanent", banned: true},
+		{name: "permanent and old", age: 24 * time.Hour, banned: true},
+		{name: "temporary", duration: time.Hour, banned: true},
+		{name: "expired", duration: time.Hour, age: 2 * time.Hour},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bans := newTestBans(t)
+			host := &state.Host{Entity: state.Entity{ID: 1}}
+			user := &state.User{Entity: state.Entity{ID: 2}}
+
+			hostBan, err := bans.BanHost(ctx, host, test.duration, "cheating", "")
+			assert.NoError(t, err)
+			userBan, err := bans.BanUser(ctx, user, test.duration, "cheating", "")
+			assert.NoError(t, err)
+			for _, ban := range []*state.Ban{hostBan, userBan} {
+				ban.Created = ban.Created.Add(-test.age)
+				if !ban.Expires.IsZero() {
+					ban.Expires = ban.Expires.Add(-test.age)
+				}
+				assert.NoError(t, bans.db.Save(ban).Error)
+			}
+
+			found, err := bans.FindHostBan(ctx, host)
+			assert.NoError(t, err)
+			assert.Equal(t, test.banned, found != nil)
+
+			found, err = bans.FindUserBan(ctx, user)
+			assert.NoError(t, err)
+			assert.Equal(t, test.banned, found != nil)
+			if found != nil {
+				assert.Equal(t, "cheating", found.Reason)
+			}
+
+			// bans only apply to who was banned
+			found, err = bans.FindHostBan(ctx, &state.Host{Entity: state.Entity{ID: 3}})
+			assert.NoError(t, err)
+			assert.Nil(t, found)
+		})
+	}
+}
+
+func TestClearServer(t *testing.T) {
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name     string
+		duration time.Duration
+		server   string
+		cleared  bool
+	}{
+		{name: "temporary", duration: time.Hour, server: "a", cleared: true},
+		{name: "permanent", server: "a"},
+		{name: "other server", duration: time.Hour, server: "b"},
+		{name: "issued by the cluster", duration: time.Hour},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bans := newTestBans(t)
+			host := &state.Host{Entity: state.Entity{ID: 1}}
+
+			_, err := bans.BanHost(ctx, host, test.duration, "", test.server)
+			assert.NoError(t, err)
+
+			assert.NoError(t, bans.ClearServer(ctx, "a"))
+
+			found, err := bans.FindHostBan(ctx, host)
+			assert.NoError(t, err)
+			assert.Equal(t, test.cleared, found == nil)
+		})
+	}
+}
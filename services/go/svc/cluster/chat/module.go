@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cfoust/sour/svc/cluster/config"
+	"github.com/cfoust/sour/svc/cluster/state"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sasha-s/go-deadlock"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionReplace = "replace"
+	ActionBlock   = "block"
+)
+
+type filter struct {
+	pattern *regexp.Regexp
+	block   bool
+}
+
+// Moderator decides what users may say: it filters messages, keeps users
+// from flooding the chat and keeps track of who is muted. Mutes of users that
+// are logged in are saved in the database, those of everyone else only last
+// until the cluster restarts.
+type Moderator struct {
+	db       *gorm.DB
+	settings config.ChatSettings
+	filters  []filter
+
+	mutex deadlock.Mutex
+	// mutes of users that aren't logged in, by host ID
+	hostMutes map[uint]*state.Mute
+}
+
+func New(db *gorm.DB, settings config.ChatSettings) *Moderator {
+	filters := make([]filter, 0)
+	for _, f := range settings.Filters {
+		pattern, err := regexp.Compile("(?i)" + f.Pattern)
+		if err != nil {
+			log.Warn().Err(err).Msgf("invalid chat filter %q", f.Pattern)
+			continue
+		}
+		filters = append(filters, filter{
+			pattern: pattern,
+			block:   f.Action == ActionBlock,
+		})
+	}
+
+	return &Moderator{
+		db:        db,
+		settings:  settings,
+		filters:   filters,
+		hostMutes: make(map[uint]*state.Mute),
+	}
+}
+
+// Filter applies the chat filters to a message. It returns false if the
+// message must not be sent at all.
+func (m *Moderator) Filter(message string) (string, bool) {
+	for _, f := range m.filters {
+		if !f.pattern.MatchString(message) {
+			continue
+		}
+		if f.block {
+			return "", false
+		}
+		message = f.pattern.ReplaceAllStringFunc(message, func(match string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(match))
+		})
+	}
+	return message, true
+}
+
+// Flood tracks when a user sent their last messages.
+type Flood struct {
+	sent []time.Time
+}
+
+// Allow records that a user wants to send a message and returns false if
+// they sent too many recently.
+func (m *Moderator) Allow(flood *Flood) bool {
+	limit := m.settings.FloodMessages
+	window := time.Duration(m.settings.FloodSeconds) * time.Second
+	if limit <= 0 || window <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	recent := flood.sent[:0]
+	for _, sent := range flood.sent {
+		if now.Sub(sent) < window {
+			recent = append(recent, sent)
+		}
+	}
+	flood.sent = recent
+
+	if len(flood.sent) >= limit {
+		return false
+	}
+	flood.sent = append(flood.sent, now)
+	return true
+}
+
+// Mute keeps a user from chatting. user is nil if they aren't logged in, in
+// which case the mute applies to their host. A duration of 0 mutes them
+// until they are unmuted.
+func (m *Moderator) Mute(ctx context.Context, host *state.Host, user *state.User, duration time.Duration, reason string) (*state.Mute, error) {
+	mute := state.Mute{
+		Created: time.Now(),
+		Reason:  reason,
+	}
+	if duration > 0 {
+		mute.Expires = mute.Created.Add(duration)
+	}
+
+	if user == nil {
+		m.mutex.Lock()
+		m.hostMutes[host.ID] = &mute
+		m.mutex.Unlock()
+		return &mute, nil
+	}
+
+	mute.UserID = user.ID
+	err := m.db.WithContext(ctx).Create(&mute).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &mute, nil
+}
+
+// Unmute lifts all mutes of a user.
+func (m *Moderator) Unmute(ctx context.Context, host *state.Host, user *state.User) error {
+	m.mutex.Lock()
+	delete(m.hostMutes, host.ID)
+	m.mutex.Unlock()
+
+	if user == nil {
+		return nil
+	}
+
+	return m.db.WithContext(ctx).
+		Where("user_id = ?", user.ID).
+		Delete(&state.Mute{}).
+		Error
+}
+
+// FindMute returns the mute currently in effect for a user, or nil if there
+// is none.
+func (m *Moderator) FindMute(ctx context.Context, host *state.Host, user *state.User) (*state.Mute, error) {
+	m.mutex.Lock()
+	mute, ok := m.hostMutes[host.ID]
+	if ok && !mute.Active() {
+		delete(m.hostMutes, host.ID)
+		ok = false
+	}
+	m.mutex.Unlock()
+	if ok {
+		return mute, nil
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	var mutes []state.Mute
+	err := m.db.WithContext(ctx).Where(&state.Mute{UserID: user.ID}).Find(&mutes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mute := range mutes {
+		if mute.Active() {
+			return &mute, nil
+		}
+	}
+
+	return nil, nil
+}
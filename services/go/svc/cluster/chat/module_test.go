@@ -0,0 +1,137 @@
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cfoust/sour/svc/cluster/config"
+	"github.com/cfoust/sour/svc/cluster/state"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	moderator := New(nil, config.ChatSettings{
+		Filters: []config.ChatFilter{
+			{Pattern: "noob", Action: ActionReplace},
+			{Pattern: "b(a|e)d word", Action: ActionBlock},
+			{Pattern: "(", Action: ActionBlock},
+		},
+	})
+
+	for _, test := range []struct {
+		message string
+		want    string
+		allowed bool
+	}{
+		{message: "gg", want: "gg", allowed: true},
+		{message: "you NOOB", want: "you ****", allowed: true},
+		{message: "noobnoob", want: "********", allowed: true},
+		{message: "what a bed word", allowed: false},
+		{message: "noob, bad word", allowed: false},
+	} {
+		t.Run(test.message, func(t *testing.T) {
+			message, allowed := moderator.Filter(test.message)
+			assert.Equal(t, test.allowed, allowed)
+			assert.Equal(t, test.want, message)
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		settings config.ChatSettings
+		// when the user sent messages before, relative to now
+		sent    []time.Duration
+		allowed bool
+	}{
+		{
+			name:    "no limit",
+			sent:    []time.Duration{0, 0, 0},
+			allowed: true,
+		},
+		{
+			name:     "below the limit",
+			settings: config.ChatSettings{FloodMessages: 3, FloodSeconds: 5},
+			sent:     []time.Duration{-time.Second, 0},
+			allowed:  true,
+		},
+		{
+			name:     "at the limit",
+			settings: config.ChatSettings{FloodMessages: 3, FloodSeconds: 5},
+			sent:     []time.Duration{-2 * time.Second, -time.Second, 0},
+		},
+		{
+			name:     "old messages",
+			settings: config.ChatSettings{FloodMessages: 3, FloodSeconds: 5},
+			sent:     []time.Duration{-10 * time.Second, -6 * time.Second, 0},
+			allowed:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			moderator := New(nil, test.settings)
+			flood := &Flood{}
+			for _, ago := range test.sent {
+				flood.sent = append(flood.sent, time.Now().Add(ago))
+			}
+			assert.Equal(t, test.allowed, moderator.Allow(flood))
+		})
+	}
+}
+
+func TestFindMute(t *testing.T) {
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name     string
+		loggedIn bool
+		duration time.Duration
+		unmute   bool
+		muted    bool
+	}{
+		{name: "permanent", muted: true},
+		{name: "temporary", duration: time.Hour, muted: true},
+		{name: "expired", duration: -time.Hour},
+		{name: "unmuted", unmute: true},
+		{name: "logged in", loggedIn: true, muted: true},
+		{name: "logged in and expired", loggedIn: true, duration: -time.Hour},
+		{name: "logged in and unmuted", loggedIn: true, unmute: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := state.InitDB(":memory:")
+			assert.NoError(t, err)
+			moderator := New(db, config.ChatSettings{})
+
+			host := &state.Host{Entity: state.Entity{ID: 1}}
+			var user *state.User
+			if test.loggedIn {
+				user = &state.User{Entity: state.Entity{ID: 2}}
+			}
+
+			mute, err := moderator.Mute(ctx, host, user, test.duration, "spam")
+			assert.NoError(t, err)
+			// Mute makes mutes without a positive duration permanent, so expire
+			// the mute by hand
+			if test.duration < 0 {
+				mute.Expires = mute.Created.Add(test.duration)
+				if user != nil {
+					assert.NoError(t, db.Save(mute).Error)
+				}
+			}
+			if test.unmute {
+				assert.NoError(t, moderator.Unmute(ctx, host, user))
+			}
+
+			found, err := moderator.FindMute(ctx, host, user)
+			assert.NoError(t, err)
+			assert.Equal(t, test.muted, found != nil)
+
+			// mutes of logged in users follow them to other hosts
+			found, err = moderator.FindMute(ctx, &state.Host{Entity: state.Entity{ID: 3}}, user)
+			assert.NoError(t, err)
+			assert.Equal(t, test.muted && test.loggedIn, found != nil)
+		})
+	}
+}
@@ -86,6 +86,20 @@ type MatchmakingSettings struct {
 	Duel []DuelType
 }
 
+type ChatFilter struct {
+	// A regular expression, matched regardless of case
+	Pattern string
+	// "replace" stars out the matches, "block" drops the whole message
+	Action string
+}
+
+type ChatSettings struct {
+	// How many messages a user may send within FloodSeconds
+	FloodMessages int
+	FloodSeconds  int
+	Filters       []ChatFilter
+}
+
 type ClusterSettings struct {
 	Enabled           bool
 	LogSessions       bool
@@ -97,6 +111,7 @@ type ClusterSettings struct {
 	Presets           []ServerPreset
 	Spaces            []PresetSpace
 	Matchmaking       MatchmakingSettings
+	Chat              ChatSettings
 	ServerDescription string
 	Ingress           ClusterIngress
 }
@@ -306,6 +306,8 @@ func (server *WSIngress) HandleClient(ctx context.Context, c *websocket.Conn, ho
 	client.deviceType = deviceType
 
 	client.session = utils.NewSession(ctx)
+	// the cluster needs the host as soon as it learns about the client
+	client.host = host
 
 	server.newClients <- client
 
@@ -314,7 +316,6 @@ func (server *WSIngress) HandleClient(ctx context.Context, c *websocket.Conn, ho
 	server.AddClient(client)
 	defer server.RemoveClient(client)
 
-	client.host = host
 	client.closeSlow = func() {
 		c.Close(websocket.StatusPolicyViolation, "connection too slow to keep up with messages")
 	}
@@ -451,6 +452,20 @@ func (server *WSIngress) HandleClient(ctx context.Context, c *websocket.Conn, ho
 			if err != nil {
 				return err
 			}
+		case <-client.session.Ctx().Done():
+			// The cluster dropped the client, but we still want them
+			// to know why
+			for {
+				select {
+				case msg := <-client.send:
+					err := WriteTimeout(ctx, time.Second*5, c, msg)
+					if err != nil {
+						return err
+					}
+				default:
+					return c.Close(websocket.StatusNormalClosure, "disconnected")
+				}
+			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/cfoust/sour/pkg/assets"
 	"github.com/cfoust/sour/svc/cluster/auth"
+	"github.com/cfoust/sour/svc/cluster/bans"
 	"github.com/cfoust/sour/svc/cluster/config"
 	"github.com/cfoust/sour/svc/cluster/ingress"
 	"github.com/cfoust/sour/svc/cluster/servers"
@@ -140,7 +141,9 @@ func main() {
 		)
 	}
 
-	serverManager := servers.NewServerManager(assetFetcher, clusterConfig.ServerDescription, clusterConfig.Presets)
+	bans := bans.New(db)
+
+	serverManager := servers.NewServerManager(assetFetcher, stores, clusterConfig.ServerDescription, clusterConfig.Presets)
 	cluster := service.NewCluster(
 		ctx,
 		serverManager,
@@ -151,6 +154,7 @@ func main() {
 		state.Client,
 		db,
 		stores,
+		bans,
 	)
 
 	err = serverManager.Start()
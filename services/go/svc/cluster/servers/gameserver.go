@@ -7,6 +7,7 @@ import (
 	P "github.com/cfoust/sour/pkg/game/protocol"
 	"github.com/cfoust/sour/pkg/maps"
 	"github.com/cfoust/sour/pkg/server"
+	"github.com/cfoust/sour/pkg/server/protocol/mastermode"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -76,14 +77,20 @@ func (server *GameServer) Shutdown() {
 }
 
 func (s *GameServer) GetServerInfo() *ServerInfo {
+	// the server browser asks for the password before connecting
+	passwordMode := int32(0)
+	if s.Password != "" {
+		passwordMode = int32(mastermode.Password)
+	}
+
 	return &ServerInfo{
 		NumClients:   int32(s.NumClients()),
 		GamePaused:   s.Clock.Paused(),
 		GameMode:     int32(s.GameMode.ID()),
 		TimeLeft:     int32(s.Clock.TimeLeft() / time.Second),
 		MaxClients:   64,
-		PasswordMode: 0,
-		GameSpeed:    100,
+		PasswordMode: passwordMode,
+		GameSpeed:    s.Speed,
 		Map:          s.Map,
 		Description:  s.Description,
 	}
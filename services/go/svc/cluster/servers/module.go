@@ -1,11 +1,15 @@
 package servers
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	_ "embed"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"math/big"
 	"strings"
 	"sync"
@@ -16,8 +20,10 @@ import (
 	P "github.com/cfoust/sour/pkg/game/protocol"
 	"github.com/cfoust/sour/pkg/maps"
 	"github.com/cfoust/sour/pkg/server"
+	"github.com/cfoust/sour/pkg/server/geom"
 	"github.com/cfoust/sour/svc/cluster/config"
 	"github.com/cfoust/sour/svc/cluster/ingress"
+	"github.com/cfoust/sour/svc/cluster/stores"
 
 	"github.com/repeale/fp-go"
 	"github.com/repeale/fp-go/option"
@@ -49,6 +55,15 @@ type ClientKick struct {
 	Client ingress.ClientID
 	Reason int32
 	Text   string
+	// How long the client should be banned for, if at all
+	Ban    time.Duration
+	Server *GameServer
+}
+
+// The stats of a match that ended on a server.
+type MatchReport struct {
+	Report *server.MatchReport
+	Server *GameServer
 }
 
 type ClientLeave struct {
@@ -68,11 +83,21 @@ type ServerManager struct {
 
 	presets []config.ServerPreset
 	Maps    *assets.AssetFetcher
+	store   *stores.AssetStorage
 
 	serverDescription string
 
-	kicks   chan ClientKick
-	packets chan ClientPacket
+	kicks     chan ClientKick
+	clearBans chan *GameServer
+	packets   chan ClientPacket
+	reports   chan MatchReport
+
+	// looks up auth keys for the servers, see server.Server.FindAuthKey
+	FindAuthKey func(domain, name string) *server.AuthKey
+	// looks up ratings for the servers, see server.Server.Rating
+	Rating func(sessionID uint32) (int, bool)
+	// looks up accounts for the servers, see server.Server.Account
+	Account func(sessionID uint32) (uint, bool)
 }
 
 func (manager *ServerManager) ReceivePackets() <-chan ClientPacket {
@@ -83,6 +108,15 @@ func (manager *ServerManager) ReceiveKicks() <-chan ClientKick {
 	return manager.kicks
 }
 
+func (manager *ServerManager) ReceiveReports() <-chan MatchReport {
+	return manager.reports
+}
+
+// Servers whose bans should be lifted.
+func (manager *ServerManager) ReceiveClearBans() <-chan *GameServer {
+	return manager.clearBans
+}
+
 func (manager *ServerManager) GetServerInfo() *ServerInfo {
 	info := ServerInfo{}
 
@@ -96,14 +130,17 @@ func (manager *ServerManager) GetServerInfo() *ServerInfo {
 	return &info
 }
 
-func NewServerManager(maps *assets.AssetFetcher, serverDescription string, presets []config.ServerPreset) *ServerManager {
+func NewServerManager(maps *assets.AssetFetcher, store *stores.AssetStorage, serverDescription string, presets []config.ServerPreset) *ServerManager {
 	return &ServerManager{
 		Servers:           make([]*GameServer, 0),
 		Maps:              maps,
+		store:             store,
 		serverDescription: serverDescription,
 		presets:           presets,
 		kicks:             make(chan ClientKick, 100),
+		clearBans:         make(chan *GameServer, 10),
 		packets:           make(chan ClientPacket, 100),
+		reports:           make(chan MatchReport, 10),
 	}
 }
 
@@ -174,7 +211,59 @@ func (manager *ServerManager) PruneServers(ctx context.Context) {
 	}
 }
 
-func (manager *ServerManager) ReadEntities(ctx context.Context, server *GameServer, data []byte) error {
+// Finds what game modes and bots need to know about a map in its entities.
+func readMapInfo(mapName string, entities []maps.Entity) server.MapInfo {
+	info := server.MapInfo{Map: mapName}
+	for i, entity := range entities {
+		position := P.Vec{
+			X: float64(entity.Position.X),
+			Y: float64(entity.Position.Y),
+			Z: float64(entity.Position.Z),
+		}
+
+		switch {
+		case entity.Type == C.EntityTypeBase:
+			info.Bases = append(info.Bases, P.ClientBaseState{
+				AmmoType: int32(entity.Attr1),
+				Position: position,
+			})
+		// the skull bases of collect modes are flags with a team
+		case entity.Type == C.EntityTypeFlag && (entity.Attr2 == 1 || entity.Attr2 == 2):
+			info.TokenBases = append(info.TokenBases, P.ClientTokenBase{
+				Team:     int32(entity.Attr2),
+				Position: position,
+			})
+		}
+
+		switch entity.Type {
+		case C.EntityTypePlayerStart,
+			C.EntityTypeShells,
+			C.EntityTypeBullets,
+			C.EntityTypeRockets,
+			C.EntityTypeRounds,
+			C.EntityTypeGrenades,
+			C.EntityTypeCartridges,
+			C.EntityTypeHealth,
+			C.EntityTypeBoost,
+			C.EntityTypeGreenArmour,
+			C.EntityTypeYellowArmour,
+			C.EntityTypeQuad,
+			C.EntityTypeTeleport,
+			C.EntityTypeTeledest:
+			info.Entities = append(info.Entities, server.MapEntity{
+				Index:    int32(i),
+				Type:     entity.Type,
+				Position: geom.NewVector(position.X, position.Y, position.Z),
+				Attr1:    entity.Attr1,
+				Attr2:    entity.Attr2,
+			})
+		}
+	}
+
+	return info
+}
+
+func (manager *ServerManager) ReadEntities(ctx context.Context, server *GameServer, mapName string, data []byte) error {
 	map_, err := maps.BasicsFromGZ(data)
 	if err != nil {
 		log.Error().Err(err).Msgf("could not read map entities")
@@ -184,6 +273,39 @@ func (manager *ServerManager) ReadEntities(ctx context.Context, server *GameServ
 	server.Mutex.Lock()
 	server.Entities = map_.Entities
 	server.Mutex.Unlock()
+
+	server.SetMapInfo(readMapInfo(mapName, map_.Entities))
+
+	return nil
+}
+
+// Calculates the CRC of the map's uncompressed data, which is what clients
+// report in N_MAPCRC.
+func mapCRC(data []byte) (uint32, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	hash := crc32.NewIEEE()
+	_, err = io.Copy(hash, reader)
+	if err != nil {
+		return 0, err
+	}
+
+	return hash.Sum32(), nil
+}
+
+func (manager *ServerManager) ReadMapCRC(ctx context.Context, server *GameServer, mapName string, data []byte) error {
+	crc, err := mapCRC(data)
+	if err != nil {
+		log.Error().Err(err).Str("map", mapName).Msg("failed to calculate map crc")
+		return err
+	}
+
+	server.SetMapCRC(mapName, int32(crc))
+
 	return nil
 }
 
@@ -205,7 +327,100 @@ func (manager *ServerManager) PollMapRequests(ctx context.Context, server *GameS
 				continue
 			}
 
-			go manager.ReadEntities(ctx, server, data)
+			go manager.ReadMapCRC(ctx, server, request, data)
+			go manager.ReadEntities(ctx, server, request, data)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (manager *ServerManager) storeDemo(ctx context.Context, server *GameServer, demo *server.Demo) {
+	logger := server.Logger()
+
+	asset, err := manager.store.Store(ctx, nil, "dmo", demo.Data)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to store demo")
+		return
+	}
+
+	logger.Info().Str("asset", asset.Hash).Msgf("stored demo %s", demo.Info)
+}
+
+// Saves the demos the server records in the asset store.
+func (manager *ServerManager) PollDemos(ctx context.Context, server *GameServer) {
+	demos := server.FinishedDemos.Subscribe()
+	defer demos.Done()
+
+	for {
+		select {
+		case demo := <-demos.Recv():
+			// don't block the server while we upload
+			go manager.storeDemo(ctx, server, demo)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Logs the players the server caught cheating.
+func (manager *ServerManager) PollCheats(ctx context.Context, server *GameServer) {
+	cheats := server.Cheats.Subscribe()
+	defer cheats.Done()
+
+	logger := server.Logger()
+	for {
+		select {
+		case cheat := <-cheats.Recv():
+			logger.Warn().
+				Uint32("session", cheat.SessionID).
+				Str("name", cheat.Name).
+				Str("action", cheat.Action).
+				Msgf("caught cheating: %s", cheat.Reason)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Passes the server's kicks and requests to clear bans on to the cluster,
+// which enforces the bans.
+func (manager *ServerManager) PollKicks(ctx context.Context, server *GameServer) {
+	kicks := server.Kicks.Subscribe()
+	defer kicks.Done()
+	bansCleared := server.BansCleared.Subscribe()
+	defer bansCleared.Done()
+
+	for {
+		select {
+		case kick := <-kicks.Recv():
+			server.kicks <- ClientKick{
+				Client: ingress.ClientID(kick.SessionID),
+				Reason: int32(kick.Reason),
+				Text:   kick.Message,
+				Ban:    kick.Ban,
+				Server: server,
+			}
+		case <-bansCleared.Recv():
+			manager.clearBans <- server
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Passes the stats of finished matches on to the cluster.
+func (manager *ServerManager) PollReports(ctx context.Context, server *GameServer) {
+	reports := server.MatchReports.Subscribe()
+	defer reports.Done()
+
+	for {
+		select {
+		case report := <-reports.Recv():
+			manager.reports <- MatchReport{
+				Report: report,
+				Server: server,
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -246,6 +461,10 @@ func (manager *ServerManager) NewServer(ctx context.Context, presetName string,
 		To:   P.NewMessageProxy(true),
 	}
 
+	server.FindAuthKey = manager.FindAuthKey
+	server.Rating = manager.Rating
+	server.Account = manager.Account
+
 	server.SetDescription(
 		strings.ReplaceAll(manager.serverDescription, "#id", server.Id),
 	)
@@ -259,6 +478,10 @@ func (manager *ServerManager) NewServer(ctx context.Context, presetName string,
 
 	go server.Poll(server.Ctx())
 	go manager.PollMapRequests(server.Ctx(), &server)
+	go manager.PollDemos(server.Ctx(), &server)
+	go manager.PollCheats(server.Ctx(), &server)
+	go manager.PollReports(server.Ctx(), &server)
+	go manager.PollKicks(server.Ctx(), &server)
 
 	go func() {
 		for {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cfoust/sour/pkg/game"
 	P "github.com/cfoust/sour/pkg/game/protocol"
+	"github.com/cfoust/sour/pkg/server"
 	"github.com/cfoust/sour/svc/cluster/auth"
 	"github.com/cfoust/sour/svc/cluster/state"
 )
@@ -121,6 +122,7 @@ func (c *Cluster) HandleDesktopLogin(ctx context.Context, user *User) error {
 	}
 
 	connect := msg.(P.Connect)
+	user.setConnectPassword(connect)
 
 	err = user.SetName(ctx, connect.Name)
 	if err != nil {
@@ -158,6 +160,40 @@ func (c *Cluster) HandleDesktopLogin(ctx context.Context, user *User) error {
 
 	c.GreetClient(ctx, user)
 
+	err = c.followInvite(ctx, user)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to join game with password")
+	}
+
 	go c.setupCubeScript(user.Ctx(), user)
 	return nil
 }
+
+// Lets users claim auth on game servers with the key the cluster generated for
+// them, which the desktop client names after the user's UUID. Game servers ask
+// from their packet loop, so we only look at the accounts of users who are
+// already logged in instead of going to the database.
+func (c *Cluster) findAuthKey(domain, name string) *server.AuthKey {
+	if domain != c.authDomain || name == "" {
+		return nil
+	}
+
+	c.Users.Mutex.RLock()
+	defer c.Users.Mutex.RUnlock()
+
+	for _, user := range c.Users.Users {
+		auth := user.GetAuth()
+		if auth == nil || auth.UUID != name || auth.PublicKey == "" {
+			continue
+		}
+
+		return &server.AuthKey{
+			Name:   name,
+			Domain: domain,
+			Key:    auth.PublicKey,
+			Role:   "auth",
+		}
+	}
+
+	return nil
+}
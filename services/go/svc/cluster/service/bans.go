@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cfoust/sour/pkg/server/protocol/disconnectreason"
+	"github.com/cfoust/sour/svc/cluster/ingress"
+	"github.com/cfoust/sour/svc/cluster/servers"
+	"github.com/cfoust/sour/svc/cluster/state"
+)
+
+var ErrBanned = fmt.Errorf("client is banned")
+
+func banMessage(ban *state.Ban) string {
+	message := "you are banned"
+	if !ban.Expires.IsZero() {
+		message += " until " + ban.Expires.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if ban.Reason != "" {
+		message += ": " + ban.Reason
+	}
+	return message
+}
+
+// Tells the client why they can't connect and drops them.
+func disconnectBanned(connection ingress.Connection, ban *state.Ban) {
+	connection.Disconnect(int(disconnectreason.IPBanned), banMessage(ban))
+	connection.Session().Cancel()
+}
+
+// Bans the host (and, if they are logged in, the account) of a user that was
+// kicked from a game server.
+func (c *Cluster) banKicked(ctx context.Context, user *User, kick servers.ClientKick) error {
+	server := kick.Server.Id
+
+	_, err := c.bans.BanHost(ctx, user.host, kick.Ban, kick.Text, server)
+	if err != nil {
+		return err
+	}
+
+	auth := user.GetAuth()
+	if auth == nil {
+		return nil
+	}
+
+	_, err = c.bans.BanUser(ctx, auth, kick.Ban, kick.Text, server)
+	return err
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfoust/sour/pkg/game"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
+	"github.com/cfoust/sour/svc/cluster/state"
+)
+
+// How long users are muted if whoever muted them didn't say.
+const defaultMuteDuration = 10 * time.Minute
+
+func muteMessage(mute *state.Mute) string {
+	message := "you are muted"
+	if !mute.Expires.IsZero() {
+		message += " until " + mute.Expires.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if mute.Reason != "" {
+		message += ": " + mute.Reason
+	}
+	return message
+}
+
+// Decides whether a user may say something and what exactly. It returns false
+// if the message must not be sent.
+func (c *Cluster) moderateChat(ctx context.Context, user *User, message string) (string, bool) {
+	logger := user.Logger()
+
+	mute, err := c.chat.FindMute(ctx, user.host, user.GetAuth())
+	if err != nil {
+		logger.Error().Err(err).Msg("could not look up mute")
+	}
+	if mute != nil {
+		user.Message(game.Red(muteMessage(mute)))
+		return "", false
+	}
+
+	if !c.chat.Allow(&user.flood) {
+		user.Message(game.Red("you are sending messages too quickly"))
+		return "", false
+	}
+
+	filtered, ok := c.chat.Filter(message)
+	if !ok {
+		user.Message(game.Red("your message was blocked by the chat filter"))
+		return "", false
+	}
+
+	return filtered, true
+}
+
+// Finds the user on the same server as user by client number or name.
+func (c *Cluster) findServerUser(user *User, target string) (*User, error) {
+	server := user.GetServer()
+	if server == nil {
+		return nil, fmt.Errorf("you are not on a server")
+	}
+
+	c.Users.Mutex.RLock()
+	defer c.Users.Mutex.RUnlock()
+
+	cn, err := strconv.Atoi(target)
+	isCN := err == nil
+
+	var found *User
+	for _, other := range c.Users.Servers[server] {
+		if isCN && other.GetClientNum() == cn {
+			return other, nil
+		}
+
+		if !strings.EqualFold(other.GetName(), target) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("more than one player is called %s, use their client number", target)
+		}
+		found = other
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("could not find player %s", target)
+	}
+	return found, nil
+}
+
+// Mutes a player on the same server as user. Only users with a higher role on
+// the server than the player may do so. minutes is the length of the mute,
+// 0 for a mute that lasts until the player is unmuted.
+func (c *Cluster) MuteUser(ctx context.Context, user *User, target string, minutes *int) error {
+	if user.GetRole() == role.None {
+		return fmt.Errorf("you must be master to mute players")
+	}
+
+	victim, err := c.findServerUser(user, target)
+	if err != nil {
+		return err
+	}
+	if victim.GetRole() >= user.GetRole() {
+		return fmt.Errorf("you can't mute %s", victim.GetName())
+	}
+
+	duration := defaultMuteDuration
+	if minutes != nil {
+		if *minutes < 0 {
+			return fmt.Errorf("the number of minutes can't be negative")
+		}
+		duration = time.Duration(*minutes) * time.Minute
+	}
+
+	reason := fmt.Sprintf("muted by %s", user.GetName())
+	_, err = c.chat.Mute(ctx, victim.host, victim.GetAuth(), duration, reason)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%s muted %s", user.GetName(), victim.GetName())
+	if duration > 0 {
+		message += fmt.Sprintf(" for %s", duration)
+	}
+	c.AnnounceInServer(ctx, user.GetServer(), message)
+	return nil
+}
+
+// Lifts the mutes of a player on the same server as user.
+func (c *Cluster) UnmuteUser(ctx context.Context, user *User, target string) error {
+	if user.GetRole() == role.None {
+		return fmt.Errorf("you must be master to unmute players")
+	}
+
+	victim, err := c.findServerUser(user, target)
+	if err != nil {
+		return err
+	}
+
+	err = c.chat.Unmute(ctx, victim.host, victim.GetAuth())
+	if err != nil {
+		return err
+	}
+
+	c.AnnounceInServer(ctx, user.GetServer(), fmt.Sprintf("%s unmuted %s", user.GetName(), victim.GetName()))
+	return nil
+}
@@ -24,7 +24,9 @@ func (server *Cluster) GivePrivateMatchHelp(ctx context.Context, user *User, gam
 
 	message := fmt.Sprintf("This is your private server. Have other players join by saying '#join %s' in any Sour server.", gameServer.Id)
 
-	if user.Connection.Type() == ingress.ClientTypeWS {
+	if gameServer.Password != "" {
+		message = fmt.Sprintf("This is your private server. Have other players join by saying '#join %s %s' in any Sour server or by entering %s as the server password.", gameServer.Id, gameServer.Password, gameServer.Password)
+	} else if user.Connection.Type() == ingress.ClientTypeWS {
 		message = fmt.Sprintf("This is your private server. Have other players join by saying '#join %s' in any Sour server or by sending the link in your URL bar. (We also copied it for you!)", gameServer.Id)
 	}
 
@@ -56,12 +58,32 @@ type CreateParams struct {
 	Map    opt.Option[string]
 	Preset opt.Option[string]
 	Mode   opt.Option[int]
+	// What other players need to join
+	Password opt.Option[string]
+	// Generate an invite code to use as the password
+	Invite bool
 }
 
 func (server *Cluster) inferCreateParams(args []string) (*CreateParams, error) {
 	params := CreateParams{}
 
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "invite" {
+			params.Invite = true
+			continue
+		}
+
+		if arg == "password" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("you must provide a password after 'password'")
+			}
+			i++
+			params.Password = opt.Some(args[i])
+			continue
+		}
+
 		mode := constants.GetModeNumber(arg)
 		if opt.IsSome(mode) {
 			params.Mode = mode
@@ -116,6 +138,17 @@ func (server *Cluster) CreateGame(ctx context.Context, params *CreateParams, use
 
 	logger = logger.With().Str("server", gameServer.Reference()).Logger()
 
+	if params.Invite {
+		code, err := generateInviteCode()
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to generate invite code")
+			return errors.New("failed to generate invite code")
+		}
+		gameServer.Password = code
+	} else if opt.IsSome(params.Password) {
+		gameServer.Password = params.Password.Value
+	}
+
 	mode := int32(params.Mode.Value)
 	if opt.IsSome(params.Mode) && !gamemode.Valid(gamemode.ID(mode)) {
 		return fmt.Errorf("game mode not yet supported")
@@ -233,9 +266,19 @@ func (s *Cluster) registerCommands() {
 	goCommand := commands.Command{
 		Name:        "go",
 		Aliases:     []string{"join"},
-		ArgFormat:   "[name|id|alias]",
+		ArgFormat:   "[name|id|alias] [password]",
 		Description: "move to a space, server, or map by name, id, or alias",
-		Callback: func(ctx context.Context, user *User, target string) error {
+		Callback: func(ctx context.Context, user *User, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("you must say where to go")
+			}
+
+			target := args[0]
+			password := ""
+			if len(args) > 1 {
+				password = args[1]
+			}
+
 			if target == "home" {
 				return s.runCommandWithTimeout(ctx, user, "home")
 			}
@@ -245,7 +288,12 @@ func (s *Cluster) registerCommands() {
 					continue
 				}
 
-				_, err := user.Connect(gameServer)
+				err := s.checkPassword(user, gameServer, password)
+				if err != nil {
+					return err
+				}
+
+				_, err = user.Connect(gameServer)
 				if err != nil {
 					return err
 				}
@@ -289,7 +337,7 @@ func (s *Cluster) registerCommands() {
 
 	createGameCommand := commands.Command{
 		Name:        "creategame",
-		ArgFormat:   "[coop|ffa|insta|ctf|..etc] [map]",
+		ArgFormat:   "[coop|ffa|insta|ctf|..etc] [map] [invite|password <password>]",
 		Description: "create a private game for you and your friends",
 		Callback: func(ctx context.Context, user *User, args []string) error {
 			if len(args) == 0 {
@@ -478,6 +526,32 @@ func (s *Cluster) registerCommands() {
 		},
 	}
 
+	statsCommand := commands.Command{
+		Name:        "stats",
+		Description: "show your stats across all matches you played while logged in",
+		Callback: func(ctx context.Context, user *User) error {
+			return s.showCareer(ctx, user)
+		},
+	}
+
+	muteCommand := commands.Command{
+		Name:        "mute",
+		ArgFormat:   "[name|cn] [minutes]",
+		Description: "keep a player on your server from chatting, for 10 minutes unless you say otherwise (0 means until they are unmuted)",
+		Callback: func(ctx context.Context, user *User, target string, minutes *int) error {
+			return s.MuteUser(ctx, user, target, minutes)
+		},
+	}
+
+	unmuteCommand := commands.Command{
+		Name:        "unmute",
+		ArgFormat:   "[name|cn]",
+		Description: "let a muted player on your server chat again",
+		Callback: func(ctx context.Context, user *User, target string) error {
+			return s.UnmuteUser(ctx, user, target)
+		},
+	}
+
 	err := s.commands.Register(
 		goCommand,
 		createGameCommand,
@@ -487,6 +561,9 @@ func (s *Cluster) registerCommands() {
 		aliasCommand,
 		descCommand,
 		editCommand,
+		statsCommand,
+		muteCommand,
+		unmuteCommand,
 	)
 
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/cfoust/sour/svc/cluster/config"
+	"github.com/cfoust/sour/svc/cluster/ingress"
 	"github.com/cfoust/sour/svc/cluster/state"
 
 	"gorm.io/gorm"
@@ -156,3 +157,26 @@ func NewELOState(duels []config.DuelType) *ELOState {
 
 	return &state
 }
+
+// Returns the average rating of a logged in user across all duel types, which
+// servers use to balance teams.
+func (c *Cluster) rating(sessionID uint32) (int, bool) {
+	user := c.Users.FindUser(ingress.ClientID(sessionID))
+	if user == nil || user.GetAuth() == nil {
+		return 0, false
+	}
+
+	user.Mutex.RLock()
+	defer user.Mutex.RUnlock()
+
+	ratings := user.ELO.Ratings
+	if len(ratings) == 0 {
+		return 0, false
+	}
+
+	sum := 0
+	for _, elo := range ratings {
+		sum += int(elo.Rating)
+	}
+	return sum / len(ratings), true
+}
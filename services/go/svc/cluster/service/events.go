@@ -181,6 +181,7 @@ func (c *Cluster) PollFromMessages(ctx context.Context, user *User) {
 	userCtx := user.Ctx()
 
 	chats := user.From.Intercept(P.N_TEXT)
+	teamChats := user.From.Intercept(P.N_SAYTEAM)
 	serverCommands := user.From.Intercept(P.N_SERVCMD)
 	blockConnecting := user.From.InterceptWith(func(code P.MessageCode) bool {
 		return !P.IsConnectingMessage(code)
@@ -280,7 +281,11 @@ func (c *Cluster) PollFromMessages(ctx context.Context, user *User) {
 		case msg := <-teleports.Receive():
 			message := msg.Message
 			teleport := message.(P.Teleport)
-			c.HandleTeleport(ctx, user, teleport.Source)
+			// Bots controlled by this client teleport too, but they
+			// should not follow links to other spaces
+			if teleport.Client == int32(user.GetClientNum()) {
+				c.HandleTeleport(ctx, user, teleport.Source)
+			}
 			msg.Pass()
 
 		case msg := <-blockConnecting.Receive():
@@ -301,12 +306,28 @@ func (c *Cluster) PollFromMessages(ctx context.Context, user *User) {
 			msg.Drop()
 
 			if !strings.HasPrefix(text, "#") {
+				text, ok := c.moderateChat(userCtx, user, text)
+				if !ok {
+					continue
+				}
+
 				// We do our own chat, don't pass on to the server
 				c.ForwardGlobalChat(userCtx, user, text)
 				continue
 			}
 
 			go c.HandleCommand(ctx, user, text[1:])
+		case msg := <-teamChats.Receive():
+			text := msg.Message.(P.SayTeam).Text
+			filtered, ok := c.moderateChat(userCtx, user, text)
+			switch {
+			case !ok:
+				msg.Drop()
+			case filtered != text:
+				msg.Replace(P.SayTeam{Text: filtered})
+			default:
+				msg.Pass()
+			}
 		case msg := <-serverCommands.Receive():
 			message := msg.Message
 			text := message.(P.ServCMD).Command
@@ -11,9 +11,12 @@ import (
 	"github.com/cfoust/sour/pkg/game/commands"
 	P "github.com/cfoust/sour/pkg/game/protocol"
 	"github.com/cfoust/sour/svc/cluster/auth"
+	"github.com/cfoust/sour/svc/cluster/bans"
+	"github.com/cfoust/sour/svc/cluster/chat"
 	"github.com/cfoust/sour/svc/cluster/config"
 	"github.com/cfoust/sour/svc/cluster/ingress"
 	"github.com/cfoust/sour/svc/cluster/servers"
+	"github.com/cfoust/sour/svc/cluster/stats"
 	"github.com/cfoust/sour/svc/cluster/stores"
 	"github.com/cfoust/sour/svc/cluster/verse"
 
@@ -53,6 +56,9 @@ type Cluster struct {
 	spaces  *verse.SpaceManager
 	verse   *verse.Verse
 	assets  *assets.AssetFetcher
+	bans    *bans.Bans
+	stats   *stats.Stats
+	chat    *chat.Moderator
 }
 
 func NewCluster(
@@ -65,10 +71,11 @@ func NewCluster(
 	redis *redis.Client,
 	db *gorm.DB,
 	store *stores.AssetStorage,
+	bans *bans.Bans,
 ) *Cluster {
 	v := verse.NewVerse(db, store)
 	server := &Cluster{
-		Users:         NewUserOrchestrator(db, v, settings.Matchmaking.Duel),
+		Users:         NewUserOrchestrator(db, v, bans, settings.Matchmaking.Duel),
 		serverCtx:     ctx,
 		settings:      settings,
 		authDomain:    authDomain,
@@ -85,9 +92,15 @@ func NewCluster(
 		verse:         v,
 		spaces:        verse.NewSpaceManager(v, serverManager, maps),
 		assets:        maps,
+		bans:          bans,
+		stats:         stats.New(db),
+		chat:          chat.New(db, settings.Chat),
 	}
 
 	server.registerCommands()
+	serverManager.FindAuthKey = server.findAuthKey
+	serverManager.Rating = server.rating
+	serverManager.Account = server.account
 
 	return server
 }
@@ -146,7 +159,9 @@ func (server *Cluster) PollServers(ctx context.Context) {
 	chanLock := chanlock.New()
 
 	forceDisconnects := server.servers.ReceiveKicks()
+	clearBans := server.servers.ReceiveClearBans()
 	gamePackets := server.servers.ReceivePackets()
+	reports := server.servers.ReceiveReports()
 
 	health := chanLock.Poll(ctx)
 
@@ -165,11 +180,26 @@ func (server *Cluster) PollServers(ctx context.Context) {
 			logger := user.Logger()
 			logger.Info().Msgf("user forcibly disconnected %d %s", event.Reason, event.Text)
 
+			if event.Ban > 0 {
+				err := server.banKicked(ctx, user, event)
+				if err != nil {
+					logger.Error().Err(err).Msg("failed to ban kicked user")
+				}
+			}
+
 			user.DisconnectFromServer()
 
 			// TODO ideally we would move clients back to the lobby if they
 			// were not kicked for violent reasons
 			user.Connection.Disconnect(int(event.Reason), event.Text)
+		case gameServer := <-clearBans:
+			err := server.bans.ClearServer(ctx, gameServer.Id)
+			if err != nil {
+				logger := gameServer.Logger()
+				logger.Error().Err(err).Msg("failed to clear bans")
+			}
+		case report := <-reports:
+			server.saveMatchReport(ctx, report)
 		case p := <-gamePackets:
 			messages := p.Messages
 			gameServer := p.Server
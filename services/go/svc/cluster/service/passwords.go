@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	P "github.com/cfoust/sour/pkg/game/protocol"
+	serverauth "github.com/cfoust/sour/pkg/server/auth"
+	"github.com/cfoust/sour/svc/cluster/servers"
+)
+
+// Private games can have a password, either one their creator picked or a
+// generated invite code. Players give it to #join. Desktop players can also
+// enter it as the server password when connecting to the cluster, which takes
+// them straight to the game.
+
+const (
+	inviteCodeLength = 6
+	// no characters that are easily confused with each other
+	inviteCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+func generateInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(inviteCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// The password a desktop client sent when it connected to the cluster. The
+// client hashed it with the client number and session ID it had been given.
+type connectPassword struct {
+	cn        int32
+	sessionID int32
+	hash      string
+}
+
+func (p *connectPassword) matches(password string) bool {
+	return p != nil && serverauth.CheckPassword(p.cn, p.sessionID, password, p.hash)
+}
+
+// Remembers the password the user sent in their N_CONNECT, if any.
+func (u *User) setConnectPassword(connect P.Connect) {
+	if connect.Password == "" {
+		return
+	}
+
+	info := u.GetServerInfo()
+	u.Mutex.Lock()
+	u.password = &connectPassword{
+		cn:        info.Client,
+		sessionID: info.SessionId,
+		hash:      connect.Password,
+	}
+	u.Mutex.Unlock()
+}
+
+func (u *User) getConnectPassword() *connectPassword {
+	u.Mutex.RLock()
+	defer u.Mutex.RUnlock()
+	return u.password
+}
+
+// Checks whether the user may join the server, given the password they
+// provided with #join, if any.
+func (c *Cluster) checkPassword(user *User, server *servers.GameServer, password string) error {
+	if server.Password == "" || password == server.Password || user.getConnectPassword().matches(server.Password) {
+		return nil
+	}
+
+	if password != "" {
+		return fmt.Errorf("wrong password")
+	}
+	return fmt.Errorf("this game needs a password, say #join %s [password]", server.Reference())
+}
+
+// Takes a desktop user that entered the password of a game when connecting to
+// the cluster straight to that game.
+func (c *Cluster) followInvite(ctx context.Context, user *User) error {
+	password := user.getConnectPassword()
+	if password == nil {
+		return nil
+	}
+
+	for _, gameServer := range c.servers.Servers {
+		if gameServer.Password == "" || !password.matches(gameServer.Password) {
+			continue
+		}
+
+		_, err := user.Connect(gameServer)
+		return err
+	}
+
+	user.Message("no game has the password you entered")
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cfoust/sour/svc/cluster/ingress"
+	"github.com/cfoust/sour/svc/cluster/servers"
+	"github.com/cfoust/sour/svc/cluster/state"
+)
+
+// Looks up the account of the user with the given session ID for the game
+// servers.
+func (c *Cluster) account(sessionID uint32) (uint, bool) {
+	user := c.Users.FindUser(ingress.ClientID(sessionID))
+	if user == nil {
+		return 0, false
+	}
+
+	auth := user.GetAuth()
+	if auth == nil {
+		return 0, false
+	}
+	return auth.ID, true
+}
+
+// Stores the stats of every logged in player that took part in the match,
+// including those that left before it ended.
+func (c *Cluster) saveMatchReport(ctx context.Context, report servers.MatchReport) {
+	logger := report.Server.Logger()
+
+	for _, player := range report.Report.Players {
+		if player.Bot || player.Account == 0 {
+			continue
+		}
+
+		var user state.User
+		err := c.db.WithContext(ctx).First(&user, player.Account).Error
+		if err != nil {
+			logger.Error().Err(err).Msgf("failed to find user %d", player.Account)
+			continue
+		}
+
+		err = c.stats.Save(ctx, &user, report.Server.Id, report.Report, player)
+		if err != nil {
+			logger.Error().Err(err).Msgf("failed to save stats for %s", user.Reference())
+		}
+	}
+}
+
+func (c *Cluster) showCareer(ctx context.Context, user *User) error {
+	auth := user.GetAuth()
+	if auth == nil {
+		return fmt.Errorf("you must be logged in to see your stats")
+	}
+
+	career, err := c.stats.Career(ctx, auth)
+	if err != nil {
+		return fmt.Errorf("failed to load stats")
+	}
+
+	user.Message(fmt.Sprintf(
+		"%d matches, %d frags, %d deaths, %d flags, %d%% accuracy, best streak %d",
+		career.Matches,
+		career.Frags,
+		career.Deaths,
+		career.Flags,
+		career.Accuracy(),
+		career.LongestStreak,
+	))
+	return nil
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/cfoust/sour/pkg/game/io"
 	P "github.com/cfoust/sour/pkg/game/protocol"
 	"github.com/cfoust/sour/pkg/server"
+	"github.com/cfoust/sour/pkg/server/protocol/role"
 	"github.com/cfoust/sour/pkg/utils"
 
+	"github.com/cfoust/sour/svc/cluster/bans"
+	"github.com/cfoust/sour/svc/cluster/chat"
 	"github.com/cfoust/sour/svc/cluster/config"
 	"github.com/cfoust/sour/svc/cluster/ingress"
 	"github.com/cfoust/sour/svc/cluster/servers"
@@ -64,6 +67,7 @@ type User struct {
 	ServerClient  *server.Client
 
 	Auth       *state.User
+	host       *state.Host
 	sessionLog *state.Session
 
 	ELO *ELOState
@@ -72,6 +76,9 @@ type User struct {
 	delayMessages bool
 	messageQueue  []string
 
+	// When the user last sent chat messages
+	flood chat.Flood
+
 	Authentication    chan *state.User
 	serverConnections chan ConnectionEvent
 
@@ -82,6 +89,9 @@ type User struct {
 	sendingMap  bool
 	autoexecKey string
 
+	// The password the user entered when connecting, if any
+	password *connectPassword
+
 	Space *verse.SpaceInstance
 
 	From *P.MessageProxy
@@ -137,6 +147,16 @@ func (u *User) Logger() zerolog.Logger {
 	return logger
 }
 
+// The role the user has on their game server.
+func (u *User) GetRole() role.ID {
+	u.Mutex.RLock()
+	defer u.Mutex.RUnlock()
+	if u.ServerClient == nil {
+		return role.None
+	}
+	return u.ServerClient.Role
+}
+
 func (u *User) GetClientNum() int {
 	u.Mutex.RLock()
 	num := int(u.ServerClient.CN)
@@ -579,11 +599,20 @@ func (u *User) HandleAuthentication(ctx context.Context, auth *state.User) error
 		return nil
 	}
 
+	ban, err := u.o.bans.FindUserBan(ctx, auth)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		disconnectBanned(u.Connection, ban)
+		return ErrBanned
+	}
+
 	u.Mutex.Lock()
 	u.Auth = auth
 	u.Mutex.Unlock()
 
-	err := u.HydrateELOState(ctx, auth)
+	err = u.HydrateELOState(ctx, auth)
 	if err != nil {
 		return err
 	}
@@ -734,15 +763,17 @@ type UserOrchestrator struct {
 
 	db    *gorm.DB
 	verse *verse.Verse
+	bans  *bans.Bans
 }
 
-func NewUserOrchestrator(db *gorm.DB, verse *verse.Verse, duels []config.DuelType) *UserOrchestrator {
+func NewUserOrchestrator(db *gorm.DB, verse *verse.Verse, bans *bans.Bans, duels []config.DuelType) *UserOrchestrator {
 	return &UserOrchestrator{
 		Duels:   duels,
 		Users:   make([]*User, 0),
 		Servers: make(map[*servers.GameServer][]*User),
 		db:      db,
 		verse:   verse,
+		bans:    bans,
 	}
 }
 
@@ -820,6 +851,15 @@ func (u *UserOrchestrator) AddUser(ctx context.Context, connection ingress.Conne
 		return nil, err
 	}
 
+	ban, err := u.bans.FindHostBan(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if ban != nil {
+		disconnectBanned(connection, ban)
+		return nil, ErrBanned
+	}
+
 	sessionID := utils.HashString(fmt.Sprintf("%d-%s", id, connection.Host()))
 	sessionLog := state.Session{
 		HostID: host.ID,
@@ -839,6 +879,7 @@ func (u *UserOrchestrator) AddUser(ctx context.Context, connection ingress.Conne
 		Status:            UserStatusDisconnected,
 		Connection:        connection,
 		Session:           connection.Session(),
+		host:              host,
 		sessionLog:        &sessionLog,
 		ELO:               NewELOState(u.Duels),
 		Name:              "unnamed",
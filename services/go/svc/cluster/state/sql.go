@@ -43,6 +43,71 @@ type Host struct {
 	UUID string
 }
 
+// Keeps a host or a user from connecting to the cluster.
+type Ban struct {
+	Entity
+	Created time.Time
+	// Zero if the ban is permanent
+	Expires time.Time
+
+	// Only one of these is set
+	HostID uint
+	Host   *Host `gorm:"foreignKey:HostID"`
+	UserID uint
+	User   *User `gorm:"foreignKey:UserID"`
+
+	Reason string
+	// The ID of the server that issued the ban, if any
+	Server string
+}
+
+func (b *Ban) Active() bool {
+	return b.Expires.IsZero() || time.Now().Before(b.Expires)
+}
+
+// Keeps a user from chatting.
+type Mute struct {
+	Entity
+	Created time.Time
+	// Zero if the mute is permanent
+	Expires time.Time
+
+	UserID uint  `gorm:"not null;index"`
+	User   *User `gorm:"foreignKey:UserID"`
+
+	Reason string
+}
+
+func (m *Mute) Active() bool {
+	return m.Expires.IsZero() || time.Now().Before(m.Expires)
+}
+
+// How a user did in a single match.
+type MatchStats struct {
+	Entity
+	Created time.Time
+	UserID  uint  `gorm:"not null;index"`
+	User    *User `gorm:"foreignKey:UserID"`
+
+	// The ID of the server the match was played on
+	Server string
+	Map    string
+	Mode   string
+
+	Frags           int32
+	Deaths          int32
+	Teamkills       int32
+	Suicides        int32
+	Flags           int32
+	DamageDealt     int32
+	DamageReceived  int32
+	DamagePotential int32
+	LongestStreak   int32
+
+	// The player's full report from the server, as JSON
+	Report string
+}
+
 // A session in a particular space, server, or map.
 type Visit struct {
 	Entity
@@ -221,6 +286,9 @@ func InitDB(path string) (*gorm.DB, error) {
 	db.AutoMigrate(&ELOType{})
 	db.AutoMigrate(&Ranking{})
 	db.AutoMigrate(&Host{})
+	db.AutoMigrate(&Ban{})
+	db.AutoMigrate(&Mute{})
+	db.AutoMigrate(&MatchStats{})
 	db.AutoMigrate(&User{})
 	db.AutoMigrate(&AuthCode{})
 	db.AutoMigrate(&Session{})
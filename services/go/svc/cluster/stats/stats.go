@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cfoust/sour/pkg/server"
+	"github.com/cfoust/sour/svc/cluster/state"
+
+	"gorm.io/gorm"
+)
+
+// Stats stores the match reports of servers for the users that played in
+// them.
+type Stats struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Stats {
+	return &Stats{
+		db: db,
+	}
+}
+
+// Save records how user did in the given match, which was played on the
+// server with the given ID.
+func (s *Stats) Save(ctx context.Context, user *state.User, serverID string, match *server.MatchReport, player server.PlayerReport) error {
+	report, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+
+	stats := state.MatchStats{
+		Created:         match.Ended,
+		UserID:          user.ID,
+		Server:          serverID,
+		Map:             match.Map,
+		Mode:            match.Mode,
+		Frags:           player.Frags,
+		Deaths:          player.Deaths,
+		Teamkills:       player.Teamkills,
+		Suicides:        player.Suicides,
+		Flags:           player.Flags,
+		DamageDealt:     player.DamageDealt,
+		DamageReceived:  player.DamageReceived,
+		DamagePotential: player.DamagePotential,
+		LongestStreak:   player.LongestStreak,
+		Report:          string(report),
+	}
+
+	return s.db.WithContext(ctx).Create(&stats).Error
+}
+
+// Career sums up all of the matches a user played.
+type Career struct {
+	Matches         int64
+	Frags           int64
+	Deaths          int64
+	Teamkills       int64
+	Suicides        int64
+	Flags           int64
+	DamageDealt     int64
+	DamageReceived  int64
+	DamagePotential int64
+	LongestStreak   int64
+}
+
+// Accuracy is the share of the potential damage the user dealt, in percent.
+func (c *Career) Accuracy() int64 {
+	if c.DamagePotential <= 0 {
+		return 0
+	}
+	return c.DamageDealt * 100 / c.DamagePotential
+}
+
+const careerColumns = `
+	count(*) as matches,
+	coalesce(sum(frags), 0) as frags,
+	coalesce(sum(deaths), 0) as deaths,
+	coalesce(sum(teamkills), 0) as teamkills,
+	coalesce(sum(suicides), 0) as suicides,
+	coalesce(sum(flags), 0) as flags,
+	coalesce(sum(damage_dealt), 0) as damage_dealt,
+	coalesce(sum(damage_received), 0) as damage_received,
+	coalesce(sum(damage_potential), 0) as damage_potential,
+	coalesce(max(longest_streak), 0) as longest_streak
+`
+
+func (s *Stats) Career(ctx context.Context, user *state.User) (*Career, error) {
+	var career Career
+	err := s.db.WithContext(ctx).
+		Model(&state.MatchStats{}).
+		Select(careerColumns).
+		Where("user_id = ?", user.ID).
+		Scan(&career).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &career, nil
+}
@@ -36,6 +36,8 @@ func (s *AssetStorage) Get(ctx context.Context, asset *state.Asset) ([]byte, err
 	return store.Get(ctx, asset.Hash)
 }
 
+// Store saves data in the default store. user may be nil for assets created
+// by the cluster itself, such as demos.
 func (s *AssetStorage) Store(ctx context.Context, user *state.User, extension string, data []byte) (*state.Asset, error) {
 	store := s.defaultStore
 	hash := utils.Hash(data)
@@ -45,8 +47,13 @@ func (s *AssetStorage) Store(ctx context.Context, user *state.User, extension st
 		return nil, err
 	}
 
+	creatable := state.Creatable{Created: time.Now()}
+	if user != nil {
+		creatable = state.NewCreatable(user)
+	}
+
 	asset := state.Asset{
-		Creatable: state.NewCreatable(user),
+		Creatable: creatable,
 		Hash:      hash,
 		Extension: extension,
 		Size:      uint(len(data)),